@@ -0,0 +1,75 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kardianos/govendor/internal/pathos"
+	"github.com/kardianos/govendor/vendorfile"
+)
+
+// AddDir vendors the package in srcDir, which need not be in GOPATH, as
+// if its import path were importPath. This allows vendoring code that was
+// received outside of version control, such as from an archive. Only the
+// package folder is copied, parent folders are not searched for licenses
+// and no revision is recorded. If imports are rewritten, imports of
+// importPath are rewritten to the vendored path. The vendor file is
+// written when done.
+func (ctx *Context) AddDir(srcDir, importPath string) error {
+	importPath = strings.Trim(path.Clean("/"+importPath), "/")
+	if len(importPath) == 0 {
+		return ErrEmptyImportPath
+	}
+	if importPath == ctx.RootImportPath || strings.HasPrefix(importPath, ctx.RootImportPath+"/") {
+		return ErrLocalPackage{ImportPath: importPath, Root: ctx.RootImportPath}
+	}
+	srcDir, err := filepath.Abs(srcDir)
+	if err != nil {
+		return err
+	}
+	hasGo, err := hasGoFileInFolder(srcDir)
+	if err != nil {
+		return err
+	}
+	if !hasGo {
+		return ErrNotInGOPATH{Missing: srcDir}
+	}
+	dest := filepath.Join(ctx.RootDir, ctx.VendorFolder, pathos.SlashToFilepath(importPath))
+	localExists, err := hasGoFileInFolder(dest)
+	if err != nil {
+		return err
+	}
+	if localExists || ctx.VendorFilePackagePath(importPath) != nil {
+		return ErrPackageExists{path.Join(ctx.InternalPrefix(), importPath)}
+	}
+	ignoreFile, _, err := ctx.getIgnoreFiles(srcDir)
+	if err != nil {
+		return err
+	}
+
+	ctx.dirty = true
+	h := sha1.New()
+	err = ctx.CopyPackage(dest, srcDir, "", importPath, ignoreFile, false, h, nil)
+	if err != nil {
+		return err
+	}
+	ctx.VendorFile.Package = append(ctx.VendorFile.Package, &vendorfile.Package{
+		Add:          true,
+		Path:         importPath,
+		ChecksumSHA1: base64.StdEncoding.EncodeToString(h.Sum(nil)),
+	})
+	if ctx.rewriteImports {
+		ctx.RewriteRule[importPath] = path.Join(ctx.InternalPrefix(), importPath)
+		if err = ctx.rewrite(); err != nil {
+			return err
+		}
+	}
+	return ctx.WriteVendorFile()
+}
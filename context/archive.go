@@ -0,0 +1,210 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kardianos/govendor/internal/pathos"
+	"github.com/kardianos/govendor/vendorfile"
+)
+
+// Export writes a tar archive of the vendor folder and the vendor file to w.
+// If gz is true the archive is gzip compressed. Entries are written in
+// lexical order with fixed modification times and owners so the same
+// vendor folder always produces the same archive. Paths in the archive
+// are relative to the project root.
+func (ctx *Context) Export(w io.Writer, gz bool) error {
+	if gz {
+		gw := gzip.NewWriter(w)
+		err := ctx.exportTar(gw)
+		if err != nil {
+			gw.Close()
+			return err
+		}
+		return gw.Close()
+	}
+	return ctx.exportTar(w)
+}
+
+func (ctx *Context) exportTar(w io.Writer) error {
+	tw := tar.NewWriter(w)
+	vendorDir := filepath.Join(ctx.RootDir, ctx.VendorFolder)
+	hasVendorFile := false
+	err := filepath.Walk(vendorDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if pathos.FileStringEquals(p, ctx.VendorFilePath) {
+			hasVendorFile = true
+		}
+		return ctx.exportFile(tw, p, info)
+	})
+	if err != nil {
+		return err
+	}
+	if !hasVendorFile {
+		info, err := os.Stat(ctx.VendorFilePath)
+		if err != nil {
+			return err
+		}
+		err = ctx.exportFile(tw, ctx.VendorFilePath, info)
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// exportFile writes a single directory or regular file. Other file types
+// are skipped.
+func (ctx *Context) exportFile(tw *tar.Writer, p string, info os.FileInfo) error {
+	rel, err := filepath.Rel(ctx.RootDir, p)
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{
+		Name:    filepath.ToSlash(rel),
+		ModTime: time.Unix(0, 0),
+	}
+	switch {
+	case info.IsDir():
+		hdr.Typeflag = tar.TypeDir
+		hdr.Name += "/"
+		hdr.Mode = 0755
+		return tw.WriteHeader(hdr)
+	case info.Mode().IsRegular():
+		hdr.Typeflag = tar.TypeReg
+		hdr.Mode = 0644
+		if info.Mode()&0111 != 0 {
+			hdr.Mode = 0755
+		}
+		hdr.Size = info.Size()
+	default:
+		return nil
+	}
+	err = tw.WriteHeader(hdr)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// Import unpacks a tar archive created by Export into the project. The
+// archive may be gzip compressed. Every entry must be inside the vendor
+// folder. Packages in the archived vendor file replace packages with the
+// same path in the project vendor file, other packages are kept.
+func (ctx *Context) Import(r io.Reader) error {
+	br := bufio.NewReader(r)
+	var src io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		src = gr
+	}
+	vendorFileName, err := filepath.Rel(ctx.RootDir, ctx.VendorFilePath)
+	if err != nil {
+		return err
+	}
+	vendorFileName = filepath.ToSlash(vendorFileName)
+	vendorFolder := path.Clean(filepath.ToSlash(ctx.VendorFolder))
+
+	var vf *vendorfile.File
+	tr := tar.NewReader(src)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		name := path.Clean(hdr.Name)
+		if name == vendorFileName {
+			vf = &vendorfile.File{}
+			err = vf.Unmarshal(tr)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if name == vendorFolder && hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+		if !strings.HasPrefix(name, vendorFolder+"/") || strings.Contains("/"+name+"/", "/../") {
+			return ErrArchivePath{Name: hdr.Name}
+		}
+		dest := filepath.Join(ctx.RootDir, filepath.FromSlash(name))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(dest, 0777)
+		case tar.TypeReg:
+			err = extractFile(dest, tr, os.FileMode(hdr.Mode).Perm())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if vf == nil {
+		return nil
+	}
+	for _, vp := range vf.Package {
+		existing := ctx.VendorFilePackagePath(vp.Path)
+		if existing == nil {
+			vp.Add = true
+			ctx.VendorFile.Package = append(ctx.VendorFile.Package, vp)
+			continue
+		}
+		// The vendor files on disk were replaced, so replace every field.
+		existing.Origin = vp.Origin
+		existing.Tree = vp.Tree
+		existing.Revision = vp.Revision
+		existing.RevisionTime = vp.RevisionTime
+		existing.Version = vp.Version
+		existing.VersionExact = vp.VersionExact
+		existing.ChecksumSHA1 = vp.ChecksumSHA1
+		existing.Comment = vp.Comment
+		existing.Upstream = vp.Upstream
+		existing.Files = vp.Files
+		existing.History = vp.History
+		existing.Exclude = vp.Exclude
+		existing.MaxFileSize = vp.MaxFileSize
+	}
+	ctx.dirty = true
+	return ctx.WriteVendorFile()
+}
+
+func extractFile(dest string, r io.Reader, perm os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(dest), 0777)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
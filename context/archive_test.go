@@ -0,0 +1,184 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/kardianos/govendor/internal/gt"
+)
+
+func TestExport(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	buf := &bytes.Buffer{}
+	g.Check(c.Export(buf, false))
+	again := &bytes.Buffer{}
+	g.Check(c.Export(again, false))
+	if !bytes.Equal(buf.Bytes(), again.Bytes()) {
+		t.Fatal("export is not reproducible")
+	}
+
+	var names []string
+	tr := tar.NewReader(buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		g.Check(err)
+		names = append(names, hdr.Name)
+	}
+	want := "vendor/ vendor/co2/ vendor/co2/pk1/ vendor/co2/pk1/a.go vendor/vendor.json"
+	if got := fmt.Sprint(names); got != "["+want+"]" {
+		t.Fatalf("got %s, want [%s]", got, want)
+	}
+}
+
+func TestExportLongPath(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	long := "co2/" + strings.Repeat("a", 120)
+	g.Setup("co1/pk1",
+		gt.File("a.go", long),
+	)
+	g.Setup(long,
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg(long), Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	buf := &bytes.Buffer{}
+	g.Check(c.Export(buf, false))
+	found := false
+	tr := tar.NewReader(buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		g.Check(err)
+		if hdr.Name == "vendor/"+long+"/a.go" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("long path not in export")
+	}
+}
+
+func TestImport(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	buf := &bytes.Buffer{}
+	g.Check(c.Export(buf, true))
+
+	g.Remove("co2/pk1")
+	g.In("co3")
+	c = ctx(g)
+	g.Check(c.Import(buf))
+
+	c = ctx(g)
+	list(g, c, "imported", `
+ v  co3/vendor/co2/pk1 [co2/pk1] < ["co3/pk1"]
+ l  co3/pk1 < []
+ s  strings < ["co3/vendor/co2/pk1"]
+`)
+	if c.VendorFilePackagePath("co2/pk1") == nil {
+		t.Fatal("co2/pk1 not in imported vendor file")
+	}
+
+	bad := &bytes.Buffer{}
+	tw := tar.NewWriter(bad)
+	g.Check(tw.WriteHeader(&tar.Header{Name: "vendor/../a.go", Typeflag: tar.TypeReg, Mode: 0644}))
+	g.Check(tw.Close())
+	if _, is := c.Import(bad).(ErrArchivePath); !is {
+		t.Fatal("expected ErrArchivePath")
+	}
+}
+
+func TestImportFileManifest(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.In("co3")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	g.Check(c.UpdateFileManifest("co2/pk1"))
+	g.Check(c.WriteVendorFile())
+
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+		gt.File("b.go", "bytes"),
+	)
+	g.In("co1")
+	c = ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	g.Check(c.UpdateFileManifest("co2/pk1"))
+	g.Check(c.WriteVendorFile())
+
+	buf := &bytes.Buffer{}
+	g.Check(c.Export(buf, true))
+
+	g.In("co3")
+	c = ctx(g)
+	g.Check(c.Import(buf))
+
+	c = ctx(g)
+	drift, err := c.VerifyFileManifest("co2/pk1")
+	g.Check(err)
+	if len(drift) != 0 {
+		t.Fatalf("got drift %q after import", drift)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+	"strings"
+
+	filepath "github.com/kardianos/govendor/internal/vfilepath"
+)
+
+// CanVendor reports if the package may be added to the vendor folder. If
+// not, reason says why. Nothing is changed, so tools may call it to offer
+// or disable adding a package.
+func (ctx *Context) CanVendor(importPath string) (ok bool, reason string, err error) {
+	importPath = strings.Trim(importPath, "/")
+	if len(importPath) == 0 {
+		return false, "import path is empty", nil
+	}
+	std, err := ctx.isStdLib(importPath)
+	if err != nil {
+		return false, "", err
+	}
+	if std {
+		return false, "standard library package", nil
+	}
+	if filepath.HasPrefixDir(importPath, ctx.RootImportPath) {
+		return false, fmt.Sprintf("package is in the project %q", ctx.RootImportPath), nil
+	}
+	if vp := ctx.VendorFilePackagePath(importPath); vp != nil {
+		return false, "package is already vendored", nil
+	}
+	if ctx.vendorFileCovers(importPath) {
+		return false, "package is inside a vendored tree package", nil
+	}
+	for _, exclude := range ctx.excludePackage {
+		if importPath == exclude || strings.HasPrefix(importPath, exclude+"/") {
+			return false, fmt.Sprintf("package is excluded by the ignore rule %q", exclude+"/"), nil
+		}
+	}
+	if _, _, err := ctx.findImportDir("", importPath); err != nil {
+		return false, "package not found in GOPATH", nil
+	}
+	return true, "", nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cgoSourceExt are the file extensions that may include C headers.
+var cgoSourceExt = map[string]bool{
+	".go":  true,
+	".c":   true,
+	".cc":  true,
+	".cpp": true,
+	".cxx": true,
+	".h":   true,
+	".hh":  true,
+	".hpp": true,
+	".m":   true,
+}
+
+// ParentHeaders finds vendored packages that include a C header from
+// outside of the package folder, such as `#include "../common.h"`. Only
+// the package folder is copied when vendoring, so these headers must be
+// vendored by hand. Alter gives the same warnings for each package it
+// copies. A warning is given for each header that is not found
+// next to the vendored package. This is best effort: only quoted includes
+// are checked and include paths from "#cgo CFLAGS" are not used.
+func (ctx *Context) ParentHeaders() ([]Warning, error) {
+	list, err := ctx.Status()
+	if err != nil {
+		return nil, err
+	}
+	var warning []Warning
+	for _, item := range list {
+		if item.Status.Location != LocationVendor {
+			continue
+		}
+		pkg := ctx.Package[item.Local]
+		if pkg == nil || len(pkg.Dir) == 0 {
+			continue
+		}
+		w, err := dirParentHeaders(pkg.Dir)
+		if err != nil {
+			return nil, err
+		}
+		warning = append(warning, w...)
+	}
+	return warning, nil
+}
+
+// copiedParentHeaders checks a package just copied into the vendor folder,
+// and with tree set every folder under it, so missing headers are reported
+// while vendoring.
+func copiedParentHeaders(dir string, tree bool) ([]Warning, error) {
+	if !tree {
+		return dirParentHeaders(dir)
+	}
+	var warning []Warning
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return err
+		}
+		w, err := dirParentHeaders(p)
+		warning = append(warning, w...)
+		return err
+	})
+	return warning, err
+}
+
+// dirParentHeaders checks the files of a single package folder, see
+// ParentHeaders. A missing folder has no warnings.
+func dirParentHeaders(dir string) ([]Warning, error) {
+	fl, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var warning []Warning
+	for _, fi := range fl {
+		if fi.IsDir() || !cgoSourceExt[filepath.Ext(fi.Name())] {
+			continue
+		}
+		w, err := parentHeaders(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return nil, err
+		}
+		warning = append(warning, w...)
+	}
+	return warning, nil
+}
+
+func parentHeaders(pathname string) ([]Warning, error) {
+	f, err := os.Open(pathname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dir := filepath.Dir(pathname)
+	var warning []Warning
+	// Read lines without a length limit, generated files may have very
+	// long lines.
+	r := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		raw, err := r.ReadString('\n')
+		if len(raw) == 0 && err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return warning, err
+		}
+		// Includes in a cgo preamble may be in line comments.
+		text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(raw), "//"))
+		if !strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimSpace(text[1:])
+		if !strings.HasPrefix(text, "include") {
+			continue
+		}
+		text = strings.TrimSpace(strings.TrimPrefix(text, "include"))
+		if len(text) < 2 || text[0] != '"' {
+			continue
+		}
+		end := strings.IndexByte(text[1:], '"')
+		if end < 0 {
+			continue
+		}
+		name := text[1 : end+1]
+		if filepath.IsAbs(name) {
+			continue
+		}
+		header := filepath.Join(dir, filepath.FromSlash(name))
+		if rel, err := filepath.Rel(dir, header); err != nil || !strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if _, err := os.Stat(header); err == nil {
+			continue
+		}
+		warning = append(warning, Warning{
+			Code:    WarnParentHeader,
+			Message: fmt.Sprintf("line %d includes %q from outside the package, which must be vendored by hand", line, name),
+			Path:    pathname,
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"strings"
+
+	"github.com/kardianos/govendor/internal/pathos"
+	filepath "github.com/kardianos/govendor/internal/vfilepath"
+)
+
+// ClassifyHypothetical returns the status the package would have if the
+// project imported it. The import path is resolved the way an import
+// from the project root would be: the standard library, then the vendor
+// folder, then GOPATH. Nothing is loaded or changed, so editors may call
+// it to hint if a new import adds an external dependency. The status type
+// is always TypePackage as the package files are not read.
+func (ctx *Context) ClassifyHypothetical(importPath string) (Status, error) {
+	importPath = strings.Trim(importPath, "/")
+	status := Status{
+		Type:     TypePackage,
+		Presence: PresenceFound,
+	}
+	std, err := ctx.isStdLib(importPath)
+	if err != nil {
+		return Status{}, err
+	}
+	if std {
+		status.Location = LocationStandard
+		return status, nil
+	}
+	for _, exclude := range ctx.excludePackage {
+		if importPath == exclude || strings.HasPrefix(importPath, exclude+"/") {
+			status.Presence = PresenceExcluded
+		}
+	}
+	dir, _, err := ctx.findImportDir(ctx.RootDir, importPath)
+	if err != nil {
+		if _, is := err.(ErrNotInGOPATH); !is {
+			return Status{}, err
+		}
+		status.Location = LocationNotFound
+		if status.Presence != PresenceExcluded {
+			status.Presence = PresenceMissing
+		}
+		return status, nil
+	}
+	vendorDir := filepath.Join(ctx.RootDir, ctx.VendorDiscoverFolder) + string(filepath.Separator)
+	switch {
+	case pathos.FileHasPrefix(dir, vendorDir):
+		status.Location = LocationVendor
+		status.Presence = PresenceFound
+	case filepath.HasPrefixDir(importPath, ctx.RootImportPath):
+		status.Location = LocationLocal
+		status.Presence = PresenceFound
+	default:
+		status.Location = LocationExternal
+	}
+	return status, nil
+}
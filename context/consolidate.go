@@ -0,0 +1,81 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/kardianos/govendor/pkgspec"
+)
+
+// Consolidate merges two vendored copies of the same code. Every import of
+// the vendored package "from" in the project, including other vendored
+// packages, is rewritten to import "to". Then "from" is removed from the
+// vendor folder and the vendor file is written.
+func (ctx *Context) Consolidate(from, to string) error {
+	if from == to {
+		return nil
+	}
+	if ctx.VendorFilePackagePath(from) == nil {
+		return ErrNotVendored{Path: from}
+	}
+	if ctx.VendorFilePackagePath(to) == nil {
+		return ErrNotVendored{Path: to}
+	}
+	vendorRoot := ctx.InternalPrefix()
+	rules := map[string]string{
+		from:                        to,
+		path.Join(vendorRoot, from): path.Join(vendorRoot, to),
+	}
+	err := ctx.rewriteProject(rules)
+	if err != nil {
+		return err
+	}
+	err = ctx.ModifyImport(&pkgspec.Pkg{Path: from}, Remove)
+	if err != nil {
+		return err
+	}
+	err = ctx.Alter()
+	vferr := ctx.WriteVendorFile()
+	if err != nil {
+		return err
+	}
+	return vferr
+}
+
+// RemoveRepo removes every vendored package from the repository at
+// repoRoot, then writes the vendor file once. It returns the import
+// paths of the removed packages.
+func (ctx *Context) RemoveRepo(repoRoot string) ([]string, error) {
+	repoRoot = strings.Trim(repoRoot, "/")
+	var removed []string
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove {
+			continue
+		}
+		if vp.Path != repoRoot && !strings.HasPrefix(vp.Path, repoRoot+"/") {
+			continue
+		}
+		removed = append(removed, vp.Path)
+	}
+	sort.Strings(removed)
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	for _, p := range removed {
+		err := ctx.ModifyImport(&pkgspec.Pkg{Path: p}, Remove)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := ctx.Alter()
+	vferr := ctx.WriteVendorFile()
+	if err != nil {
+		return nil, err
+	}
+	return removed, vferr
+}
@@ -36,8 +36,53 @@ func dprintf(f string, v ...interface{}) {
 
 // Context represents the current project context.
 type Context struct {
-	Logger   io.Writer // Write to the verbose log.
-	Insecure bool      // Allow insecure network operations
+	Logger     io.Writer // Write to the verbose log.
+	Verbosity  int       // Progress written to Logger, 1 reports each package copied, removed or rewritten.
+	Insecure   bool      // Allow insecure network operations
+	CgoEnabled bool      // Count imports of files that need cgo. Set from CGO_ENABLED.
+
+	// HistoryLimit is the number of previous revisions to keep for each
+	// package in the vendor file when a package changes revision.
+	// Zero keeps no history.
+	HistoryLimit int
+
+	// ExcludeDir lists directory globs, relative to the package root, that
+	// are not copied when adding a tree package. They are recorded in the
+	// vendor file so later updates keep excluding them.
+	ExcludeDir []string
+
+	// PreserveDirTime sets the modification time of copied package
+	// directories to that of the source directories. File modification
+	// times are always kept.
+	PreserveDirTime bool
+
+	// CollectErrors continues loading packages after an error, including
+	// files that fail to parse, and returns every error together as
+	// LoadErrors. By default loading stops at the first error and parse
+	// errors are ignored.
+	CollectErrors bool
+
+	// BackupRewrite copies each file to a file with a ".govendor.bak" suffix
+	// before its imports are rewritten. An existing backup is kept.
+	// RemoveRewriteBackups removes the copies.
+	BackupRewrite bool
+
+	// MaxFileSize is the size in bytes above which a file is not copied
+	// into the vendor folder, such as large test data or binaries. Go
+	// files are always copied. A warning is given for each skipped file.
+	// The limit is saved for each added package and used when it is
+	// copied again. Zero or less uses the saved limit.
+	MaxFileSize int64
+
+	// Workers is the number of files parsed at once when loading the
+	// project packages. Lower it to avoid thrashing slow or network disks.
+	// Zero or less uses GOMAXPROCS.
+	Workers int
+
+	// MagicImport lists import paths that are always treated as standard
+	// library packages, so they are never missing or vendored. Sub-packages
+	// of a listed path are included.
+	MagicImport []string
 
 	GopathList []string // List of GOPATHs in environment. Includes "src" dir.
 	Goroot     string   // The path to the standard library.
@@ -65,13 +110,24 @@ type Context struct {
 
 	Operation []*Operation
 
+	// Warning lists non-fatal problems found while modifying the project.
+	Warning []Warning
+
 	loaded, dirty  bool
 	rewriteImports bool
 
 	ignoreTag      []string // list of tags to ignore
 	excludePackage []string // list of package prefixes to exclude
+	scopeDir       string   // only look for packages in this directory, relative to RootDir
+
+	gopathImportPath string // RootImportPath as found from the GOPATH.
+
+	// copyRewriteRule rewrites imports of Go files as they are copied,
+	// so they need not be rewritten again after the copy.
+	copyRewriteRule map[string]string
 
 	statusCache []StatusItem
+	loadErrors  LoadErrors
 	added       map[string]bool
 }
 
@@ -82,6 +138,7 @@ type Package struct {
 
 	Status Status // Status and location of the package.
 	*pkgspec.Pkg
+	Name   string // Declared package name, may differ from the directory name.
 	Local  string // Current location of a package relative to $GOPATH/src.
 	Gopath string // Includes trailing "src".
 	Files  []*File
@@ -89,6 +146,9 @@ type Package struct {
 	inVendor bool // Different than Status.Location, this is in *any* vendor tree.
 	inTree   bool
 
+	hasSource  bool // Has at least one non-test file.
+	buildsHere bool // At least one non-test file builds on this platform.
+
 	ignoreFile []string
 
 	// used in resolveUnknown function. Not persisted.
@@ -104,12 +164,21 @@ type File struct {
 	ImportComment string
 }
 
+// RootType selects how NewContextWD finds the project root.
 type RootType byte
 
 const (
+	// RootVendor requires a vendor folder in the working directory or a
+	// parent. Use for commands that change the vendor folder.
 	RootVendor RootType = iota
+	// RootWD uses the working directory.
 	RootWD
+	// RootVendorOrWD falls back to the working directory if no vendor folder
+	// is found. Use for read only commands, a missing vendor file is
+	// treated as empty.
 	RootVendorOrWD
+	// RootVendorOrWDOrFirstGOPATH falls back to the first GOPATH if no
+	// vendor folder is found.
 	RootVendorOrWDOrFirstGOPATH
 )
 
@@ -149,6 +218,13 @@ func NewEnv() (Env, error) {
 	return env, nil
 }
 
+// pathOverlap reports if either directory is the same as or inside the other.
+func pathOverlap(a, b string) bool {
+	a = strings.TrimRight(a, `\/`) + string(filepath.Separator)
+	b = strings.TrimRight(b, `\/`) + string(filepath.Separator)
+	return pathos.FileHasPrefix(a, b) || pathos.FileHasPrefix(b, a)
+}
+
 // NewContextWD creates a new context. It looks for a root folder by finding
 // a vendor file.
 func NewContextWD(rt RootType) (*Context, error) {
@@ -236,6 +312,11 @@ func NewContext(root, vendorFilePathRel, vendorFolder string, rewriteImports boo
 		if err != nil {
 			return nil, err
 		}
+		// A GOPATH that overlaps GOROOT would classify standard packages
+		// as local or external.
+		if pathOverlap(srcPath, goroot) || pathOverlap(srcPathEvaled, goroot) {
+			return nil, ErrGopathGoroot{Gopath: gopath, Goroot: env["GOROOT"]}
+		}
 		gopathGoroot = append(gopathGoroot, srcPath, srcPathEvaled+string(filepath.Separator))
 	}
 
@@ -258,6 +339,9 @@ func NewContext(root, vendorFilePathRel, vendorFolder string, rewriteImports boo
 
 		RewriteRule: make(map[string]string, 3),
 
+		CgoEnabled:  env["CGO_ENABLED"] != "0",
+		MagicImport: []string{"builtin", "unsafe", "C"},
+
 		rewriteImports: rewriteImports,
 	}
 
@@ -265,8 +349,9 @@ func NewContext(root, vendorFilePathRel, vendorFolder string, rewriteImports boo
 	if err != nil {
 		return nil, err
 	}
+	ctx.gopathImportPath = ctx.RootImportPath
 
-	vf, err := readVendorFile(path.Join(ctx.RootImportPath, vendorFolder)+"/", vendorFilePath)
+	vf, err := readVendorFile(ctx.InternalPrefix()+"/", vendorFilePath)
 	if err != nil {
 		if !os.IsNotExist(err) {
 			return nil, err
@@ -304,6 +389,65 @@ func (ctx *Context) IgnoreBuildAndPackage(ignore string) {
 	}
 }
 
+// Scope limits the project packages that are loaded to those in dir and
+// its sub-directories. Imports are still resolved against the whole
+// project, including the vendor folder. A relative dir is relative to
+// the project root. An empty dir removes the limit.
+func (ctx *Context) Scope(dir string) error {
+	ctx.dirty = true
+	ctx.scopeDir = ""
+	if len(dir) == 0 {
+		return nil
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(ctx.RootDir, dir)
+	}
+	rel, err := filepath.Rel(ctx.RootDir, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ErrNotInRoot{Dir: dir, Root: ctx.RootDir}
+	}
+	if rel != "." {
+		ctx.scopeDir = rel
+	}
+	return nil
+}
+
+// SetRootImportPath overrides the project import path found from the
+// GOPATH, for projects that are built under a different import path than
+// their location suggests. Packages in the project, including vendored
+// packages, are then resolved under the given import path.
+func (ctx *Context) SetRootImportPath(importPath string) error {
+	importPath = strings.Trim(path.Clean("/"+importPath), "/")
+	if len(importPath) == 0 {
+		return ErrEmptyRootImportPath
+	}
+	ctx.dirty = true
+	ctx.RootImportPath = importPath
+	return nil
+}
+
+// InternalPrefix returns the import path of the vendor folder, such as
+// "github.com/user/project/vendor" or ".../internal". The vendor folder name
+// is honored. Vendored packages have this prefix followed by a slash.
+func (ctx *Context) InternalPrefix() string {
+	return path.Join(ctx.RootImportPath, ctx.VendorFolder)
+}
+
+// projectImportPath translates an import path found from the GOPATH to
+// the project import path, if the root import path was overridden.
+func (ctx *Context) projectImportPath(importPath string) string {
+	if ctx.gopathImportPath == ctx.RootImportPath {
+		return importPath
+	}
+	if importPath == ctx.gopathImportPath {
+		return ctx.RootImportPath
+	}
+	if strings.HasPrefix(importPath, ctx.gopathImportPath+"/") {
+		return ctx.RootImportPath + strings.TrimPrefix(importPath, ctx.gopathImportPath)
+	}
+	return importPath
+}
+
 // Write to the set io.Writer for logging.
 func (ctx *Context) Write(s []byte) (int, error) {
 	if ctx.Logger != nil {
@@ -312,6 +456,19 @@ func (ctx *Context) Write(s []byte) (int, error) {
 	return len(s), nil
 }
 
+// verbose reports if progress at the given level is written to Logger.
+func (ctx *Context) verbose(level int) bool {
+	return ctx.Logger != nil && ctx.Verbosity >= level
+}
+
+// logf writes progress to Logger if Verbosity is at least level.
+func (ctx *Context) logf(level int, format string, v ...interface{}) {
+	if !ctx.verbose(level) {
+		return
+	}
+	fmt.Fprintf(ctx.Logger, format, v...)
+}
+
 // VendorFilePackagePath finds a given vendor file package give the import path.
 func (ctx *Context) VendorFilePackagePath(path string) *vendorfile.Package {
 	for _, pkg := range ctx.VendorFile.Package {
@@ -325,6 +482,23 @@ func (ctx *Context) VendorFilePackagePath(path string) *vendorfile.Package {
 	return nil
 }
 
+// FindByVendor finds the vendor file package by its vendored import path,
+// such as "github.com/a/b".
+func (ctx *Context) FindByVendor(path string) (*vendorfile.Package, bool) {
+	vp := ctx.VendorFilePackagePath(path)
+	return vp, vp != nil
+}
+
+// FindByLocal finds the vendor file package by its import path in the
+// project, such as "project/vendor/github.com/a/b".
+func (ctx *Context) FindByLocal(path string) (*vendorfile.Package, bool) {
+	prefix := ctx.InternalPrefix() + "/"
+	if !strings.HasPrefix(path, prefix) {
+		return nil, false
+	}
+	return ctx.FindByVendor(strings.TrimPrefix(path, prefix))
+}
+
 // findPackageChild finds any package under the current package.
 // Used for finding tree overlaps.
 func (ctx *Context) findPackageChild(ck *Package) []*Package {
@@ -377,6 +551,10 @@ func (ctx *Context) updatePackageReferences() {
 		} else {
 			pathUnderDirLookup[dir] = make(map[string]*Package)
 		}
+		// Use the nearest vendor folder, as nested vendor folders
+		// shadow those closer to the project root.
+		var found *Package
+		foundLen := -1
 		for _, pkg := range ctx.Package {
 			if !pkg.inVendor {
 				continue
@@ -394,11 +572,12 @@ func (ctx *Context) updatePackageReferences() {
 			if pkg.Path != path {
 				continue
 			}
-			pathUnderDirLookup[dir][path] = pkg
-			return pkg
+			if nextLen > foundLen {
+				found, foundLen = pkg, nextLen
+			}
 		}
-		pathUnderDirLookup[dir][path] = nil
-		return nil
+		pathUnderDirLookup[dir][path] = found
+		return found
 	}
 	for _, pkg := range ctx.Package {
 		pkg.referenced = make(map[string]*Package, len(pkg.referenced))
@@ -9,14 +9,18 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/kardianos/govendor/internal/gt"
 	"github.com/kardianos/govendor/internal/pathos"
 	"github.com/kardianos/govendor/pkgspec"
+	"github.com/kardianos/govendor/vendorfile"
 )
 
 var relVendorFile = filepath.Join("vendor", "vendor.json")
@@ -1511,3 +1515,2193 @@ func TestOrginRetain(t *testing.T) {
 }
 `)
 }
+
+func TestConsolidate(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.ModifyImport(pkg("co3/pk1"), Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	g.Check(c.Consolidate("co2/pk1", "co3/pk1"))
+
+	c = ctx(g)
+	list(g, c, "consolidated", `
+ v  co1/vendor/co3/pk1 [co3/pk1] < ["co1/pk1"]
+ l  co1/pk1 < []
+ s  strings < ["co1/vendor/co3/pk1"]
+`)
+}
+
+func TestCgoDisabled(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+		gt.File("b.go", "C", "co2/pk2"),
+		gt.FileBuild("c.go", "cgo", "co2/pk3"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co2/pk2",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co2/pk3",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	c.CgoEnabled = false
+
+	list(g, c, "cgo disabled", `
+ e  co2/pk1 < ["co1/pk1"]
+ l  co1/pk1 < []
+ s  strings < ["co2/pk1"]
+`)
+}
+
+func TestMissingSubpackages(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co2/pk1/sub", "co3/pk1"),
+	)
+	c = ctx(g)
+	missing, err := c.MissingSubpackages()
+	g.Check(err)
+	if len(missing) != 1 || missing[0] != "co2/pk1/sub" {
+		t.Fatalf("got %q, want [co2/pk1/sub]", missing)
+	}
+}
+
+func TestStatusDirect(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "co3/pk1"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyStatus(StatusGroup{
+		Status: []Status{{Location: LocationExternal}},
+	}, Add))
+	g.Check(c.Alter())
+
+	list, err := c.Status()
+	g.Check(err)
+	direct := map[string]bool{}
+	for _, item := range list {
+		if item.Status.Location == LocationVendor {
+			direct[item.Pkg.Path] = item.Direct
+		}
+	}
+	if len(direct) != 2 || !direct["co2/pk1"] || direct["co3/pk1"] {
+		t.Fatalf("got %v, want co2/pk1 direct and co3/pk1 not direct", direct)
+	}
+}
+
+func TestPackageNameDiffersFromDir(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1-go"),
+	)
+	g.Setup("co2/pk1-go",
+		gt.FilePkgBuild("a.go", "pk1", "", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1-go"), Add))
+	g.Check(c.Alter())
+
+	list(g, c, "vendored", `
+ v  co1/vendor/co2/pk1-go [co2/pk1-go] < ["co1/pk1"]
+ l  co1/pk1 < []
+ s  strings < ["co1/vendor/co2/pk1-go"]
+`)
+	items, err := c.Status()
+	g.Check(err)
+	if items[0].Name != "pk1" {
+		t.Fatalf("got package name %q, want %q", items[0].Name, "pk1")
+	}
+}
+
+func TestVendorFileValidator(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	defer func() { vendorFileValidators = nil }()
+	RegisterVendorFileValidator(func(vf *vendorfile.File) error {
+		for _, vp := range vf.Package {
+			if len(vp.Comment) == 0 {
+				return fmt.Errorf("package %q requires a comment", vp.Path)
+			}
+		}
+		return nil
+	})
+	_, err := NewContext(g.Current(), relVendorFile, "vendor", false)
+	if err == nil || err.Error() != `package "co2/pk1" requires a comment` {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+}
+
+func TestFileManifest(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+		gt.File("b.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	g.Check(c.UpdateFileManifest("co2/pk1"))
+	g.Check(c.WriteVendorFile())
+
+	g.Setup("co1/vendor/co2/pk1",
+		gt.File("b.go", "bytes"),
+		gt.File("c.go", "bytes"),
+	)
+	c = ctx(g)
+	drift, err := c.VerifyFileManifest("co2/pk1")
+	g.Check(err)
+	if got := strings.Join(drift, " "); got != "b.go c.go" {
+		t.Fatalf("got drift %q, want %q", got, "b.go c.go")
+	}
+}
+
+func TestScope(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co1/pk2",
+		gt.File("a.go", "co2/pk2"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co2/pk2",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyStatus(StatusGroup{
+		Status: []Status{{Location: LocationExternal}},
+	}, Add))
+	g.Check(c.Alter())
+
+	g.Check(c.Scope("pk1"))
+	list(g, c, "scoped", `
+ v  co1/vendor/co2/pk1 [co2/pk1] < ["co1/pk1"]
+ l  co1/pk1 < []
+ s  strings < ["co1/vendor/co2/pk1"]
+`)
+	if _, is := c.Scope("..").(ErrNotInRoot); !is {
+		t.Fatal("expected ErrNotInRoot")
+	}
+}
+
+func TestMagicImport(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "appengine/datastore", "unsafe"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	c.MagicImport = append(c.MagicImport, "appengine")
+
+	list(g, c, "magic", `
+ l  co1/pk1 < []
+ s  appengine/datastore < ["co1/pk1"]
+ s  unsafe < ["co1/pk1"]
+`)
+}
+
+func TestInactive(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.FileBuild("a.go", "neverbuilt", "co2/pk2"),
+	)
+	g.Setup("co2/pk2",
+		gt.FileBuild("a.go", "neverbuilt", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyStatus(StatusGroup{
+		Status: []Status{{Location: LocationExternal}},
+	}, Add))
+	g.Check(c.Alter())
+	list(g, c, "inactive", `
+ vi co1/vendor/co2/pk1 [co2/pk1] < ["co1/pk1"]
+ vi co1/vendor/co2/pk2 [co2/pk2] < ["co1/vendor/co2/pk1"]
+ l  co1/pk1 < []
+ s  strings < ["co1/vendor/co2/pk2"]
+`)
+
+	// Without an importer, an inactive package is still not unused.
+	g.Setup("co1/pk1",
+		gt.File("a.go", "strings"),
+	)
+	c = ctx(g)
+	list(g, c, "not unused", `
+ vi co1/vendor/co2/pk1 [co2/pk1] < []
+ vi co1/vendor/co2/pk2 [co2/pk2] < ["co1/vendor/co2/pk1"]
+ l  co1/pk1 < []
+ s  strings < ["co1/pk1" "co1/vendor/co2/pk2"]
+`)
+}
+
+func TestRename(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "old/pk1", "old/pk1/sub"),
+	)
+	g.Setup("old/pk1",
+		gt.File("a.go", "old/pk1/sub"),
+	)
+	g.Setup("old/pk1/sub",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyStatus(StatusGroup{
+		Status: []Status{{Location: LocationExternal}},
+	}, Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	g.Check(c.Rename("old", "new"))
+
+	c = ctx(g)
+	list(g, c, "renamed", `
+ v  co1/vendor/new/pk1 [new/pk1] < ["co1/pk1"]
+ v  co1/vendor/new/pk1/sub [new/pk1/sub] < ["co1/pk1" "co1/vendor/new/pk1"]
+ l  co1/pk1 < []
+ s  strings < ["co1/vendor/new/pk1/sub"]
+`)
+	vendorFile(g, "renamed", `
+{
+	"comment": "",
+	"ignore": "",
+	"package": [
+		{
+			"checksumSHA1": "",
+			"path": "new/pk1",
+			"revision": ""
+		},
+		{
+			"checksumSHA1": "",
+			"path": "new/pk1/sub",
+			"revision": ""
+		}
+	],
+	"rootPath": "co1"
+}`, `"checksumSHA1": `)
+	if _, err := os.Stat(filepath.Join(g.Current(), "vendor", "old")); !os.IsNotExist(err) {
+		t.Fatal("old vendor folder not removed")
+	}
+	verifyChecksum(g, c, "renamed")
+}
+
+func TestRenameConflict(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "old/pk1", "new/pk1"),
+	)
+	g.Setup("old/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("new/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyStatus(StatusGroup{
+		Status: []Status{{Location: LocationExternal}},
+	}, Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+	fp := g.Path("co1/pk1/a.go")
+	before, err := ioutil.ReadFile(fp)
+	g.Check(err)
+
+	err = c.Rename("old", "new")
+	if _, is := err.(ErrPackageExists); !is {
+		t.Fatalf("expected package exists error, got %v", err)
+	}
+	after, err := ioutil.ReadFile(fp)
+	g.Check(err)
+	if !bytes.Equal(before, after) {
+		t.Fatalf("imports rewritten on conflict:\n%s", after)
+	}
+	if _, err := os.Stat(g.Path("co1/vendor/old/pk1")); err != nil {
+		t.Fatal("old vendor folder moved")
+	}
+}
+
+func TestRemoveRepo(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co2/pk1/sub", "co3/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co2/pk1/sub",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyStatus(StatusGroup{
+		Status: []Status{{Location: LocationExternal}},
+	}, Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	removed, err := c.RemoveRepo("co2/pk1")
+	g.Check(err)
+	if got := strings.Join(removed, " "); got != "co2/pk1 co2/pk1/sub" {
+		t.Fatalf("got removed %q", got)
+	}
+	c = ctx(g)
+	list(g, c, "removed", `
+ v  co1/vendor/co3/pk1 [co3/pk1] < ["co1/pk1"]
+ e  co2/pk1 < ["co1/pk1"]
+ e  co2/pk1/sub < ["co1/pk1"]
+ l  co1/pk1 < []
+ s  strings < ["co2/pk1" "co2/pk1/sub" "co1/vendor/co3/pk1"]
+`)
+}
+
+func TestCheckVendorLayout(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1/^"), Add))
+	g.Check(c.Alter())
+
+	got := c.CheckVendorLayout([]*pkgspec.Pkg{
+		pkg("co2/pk1/sub"),
+		pkg("co3/pk1::co4/pk1"),
+		pkg("co3/pk1::co5/pk1"),
+		pkg("co3/PK1"),
+		pkg("co6/pk1"),
+	})
+	expect := []WarningCode{WarnLayoutNested, WarnLayoutDuplicate, WarnLayoutCase, WarnLayoutCase}
+	if len(got) != len(expect) {
+		t.Fatalf("got %d warnings, want %d: %v", len(got), len(expect), got)
+	}
+	for i, w := range got {
+		if w.Code != expect[i] {
+			t.Errorf("warning %d: got %q, want %q: %v", i, w.Code, expect[i], w)
+		}
+	}
+}
+
+func TestExcludeDir(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co2/pk1/docs",
+		gt.File("doc.go", "strings"),
+	)
+	g.Setup("co2/pk1/cmd/tool",
+		gt.File("main.go", "strings"),
+	)
+	g.Setup("co2/pk1/go_code",
+		gt.File("stub.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	c.ExcludeDir = []string{"docs", "cmd/*"}
+
+	g.Check(c.ModifyImport(pkg("co2/pk1/^"), Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	tree(g, "co1 after add tree", `
+/pk1/a.go
+/vendor/co2/pk1/a.go
+/vendor/co2/pk1/go_code/stub.go
+/vendor/vendor.json
+`)
+	vendorFile(g, "", `{
+	"comment": "",
+	"ignore": "",
+	"package": [
+		{
+			"checksumSHA1": "+mt+t4pTD5PLJ2ncHqrT3eg3r+Q=",
+			"exclude": [
+				"docs",
+				"cmd/*"
+			],
+			"path": "co2/pk1",
+			"revision": "",
+			"tree": true
+		}
+	],
+	"rootPath": "co1"
+}
+`)
+
+	// Updating keeps the exclusions recorded in the vendor file.
+	c = ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1/^"), Update))
+	g.Check(c.Alter())
+	tree(g, "co1 after update tree", `
+/pk1/a.go
+/vendor/co2/pk1/a.go
+/vendor/co2/pk1/go_code/stub.go
+/vendor/vendor.json
+`)
+}
+
+func TestOrphanDirs(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	g.Check(os.MkdirAll(g.Path("co1/vendor/co2/pk1/sub"), 0777))
+	g.Check(os.MkdirAll(g.Path("co1/vendor/co9/old"), 0777))
+	g.Check(ioutil.WriteFile(g.Path("co1/vendor/co9/old/README"), []byte("old"), 0666))
+
+	c = ctx(g)
+	orphan, err := c.RemoveOrphanDirs()
+	g.Check(err)
+	if got := strings.Join(orphan, " "); got != "co2/pk1/sub co9" {
+		t.Fatalf("got orphans %q", got)
+	}
+	tree(g, "co1 after cleanup", `
+/pk1/a.go
+/vendor/co2/pk1/a.go
+/vendor/vendor.json
+`)
+	orphan, err = c.OrphanDirs()
+	g.Check(err)
+	if len(orphan) != 0 {
+		t.Fatalf("got orphans after cleanup %q", orphan)
+	}
+}
+
+func TestVerbosity(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+		gt.File("b.go", "strings"),
+	)
+	g.In("co1")
+
+	// Silent unless a verbosity is set.
+	buf := &bytes.Buffer{}
+	c := ctx(g)
+	c.Logger = buf
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output, got %q", buf.String())
+	}
+
+	c = ctx(g)
+	c.Logger = buf
+	c.Verbosity = 1
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Update))
+	g.Check(c.Alter())
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Remove))
+	g.Check(c.Alter())
+	if got, want := buf.String(), "copying co2/pk1 (2 files)\nremoving co2/pk1\n"; got != want {
+		t.Fatalf("got output %q, want %q", got, want)
+	}
+}
+
+func TestStrayVendorImports(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co1/vendor/co2/pk1", "co1/vendor/co9/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	c = ctx(g)
+	warning, err := c.StrayVendorImports()
+	g.Check(err)
+	if len(warning) != 1 {
+		t.Fatalf("got %d warnings, want 1: %v", len(warning), warning)
+	}
+	w := warning[0]
+	if w.Code != WarnStrayVendorImport || !strings.Contains(w.Message, `"co1/vendor/co9/pk1"`) {
+		t.Fatalf("unexpected warning %v", w)
+	}
+}
+
+func TestSetRootImportPath(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "example.com/proj/pk2"),
+	)
+	g.Setup("co1/pk2",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.SetRootImportPath("example.com/proj/"))
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	list(g, c, "override root", `
+ v  example.com/proj/vendor/co2/pk1 [co2/pk1] < ["example.com/proj/pk1"]
+ l  example.com/proj/pk1 < []
+ l  example.com/proj/pk2 < ["example.com/proj/pk1"]
+ s  strings < ["example.com/proj/vendor/co2/pk1" "example.com/proj/pk2"]
+`)
+	vendorFile(g, "", `{
+	"comment": "",
+	"ignore": "",
+	"package": [
+		{
+			"checksumSHA1": "",
+			"path": "co2/pk1",
+			"revision": ""
+		}
+	],
+	"rootPath": "example.com/proj"
+}
+`, `"checksumSHA1": `)
+}
+
+func TestUpstream(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1::co3/pk1"), Add))
+	g.Check(c.Alter())
+	if err := c.SetUpstream("co9/pk1", "co2/pk1"); err == nil {
+		t.Fatal("expected error for package not in vendor file")
+	}
+	g.Check(c.SetUpstream("co2/pk1", "co2/pk1"))
+	g.Check(c.WriteVendorFile())
+
+	vendorFile(g, "", `{
+	"comment": "",
+	"ignore": "",
+	"package": [
+		{
+			"checksumSHA1": "",
+			"origin": "co3/pk1",
+			"path": "co2/pk1",
+			"revision": "",
+			"upstream": "co2/pk1"
+		}
+	],
+	"rootPath": "co1"
+}
+`, `"checksumSHA1": `)
+
+	c = ctx(g)
+	forks := c.Forks()
+	if len(forks) != 1 || forks[0].Upstream != "co2/pk1" || forks[0].Origin != "co3/pk1" {
+		t.Fatalf("unexpected forks %v", forks)
+	}
+}
+
+func TestAddPreview(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+		gt.File("b.go", "strings"),
+	)
+	g.Setup("co1/pk2",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+
+	preview, err := c.AddPreview("co2/pk1")
+	g.Check(err)
+	if preview.Local != "co1/vendor/co2/pk1" || preview.From != "co2/pk1" || preview.Rewrite {
+		t.Fatalf("unexpected preview %#v", preview)
+	}
+	want := []string{
+		filepath.Join(g.Current(), "pk1", "a.go"),
+		filepath.Join(g.Current(), "pk2", "a.go"),
+	}
+	if strings.Join(preview.Files, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got files %q, want %q", preview.Files, want)
+	}
+	tree(g, "co1 unchanged", `
+/pk1/a.go
+/pk1/b.go
+/pk2/a.go
+`)
+
+	c, err = NewContext(g.Current(), filepath.Join("internal", "vendor.json"), "internal", true)
+	g.Check(err)
+	preview, err = c.AddPreview("co2/pk1")
+	g.Check(err)
+	if len(preview.Diff) != 2 {
+		t.Fatalf("got diffs %q", preview.Diff)
+	}
+	if diff := preview.Diff[want[0]]; !strings.Contains(diff, "-\t`co2/pk1`\n+\t\"co1/internal/co2/pk1\"\n") {
+		t.Fatalf("got diff\n%s", diff)
+	}
+}
+
+func TestAsmAndCgoOnly(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/asm", "co2/cgo"),
+	)
+	g.Setup("co2/asm",
+		gt.File("stub.go"),
+	)
+	g.Setup("co2/cgo",
+		gt.File("a.go", "C"),
+	)
+	g.Check(ioutil.WriteFile(g.Path("co2/asm/asm_amd64.s"), []byte("TEXT ·f(SB),0,$0\n"), 0666))
+	g.Check(ioutil.WriteFile(g.Path("co2/cgo/a.c"), []byte("int x;\n"), 0666))
+	g.Check(ioutil.WriteFile(g.Path("co2/cgo/a.h"), []byte("int x;\n"), 0666))
+	g.In("co1")
+	c := ctx(g)
+	c.CgoEnabled = false
+
+	list(g, c, "before add", `
+ e  co2/asm < ["co1/pk1"]
+ ei co2/cgo < ["co1/pk1"]
+ l  co1/pk1 < []
+`)
+	g.Check(c.ModifyStatus(StatusGroup{
+		Status: []Status{{Location: LocationExternal}},
+	}, Add))
+	g.Check(c.Alter())
+
+	list(g, c, "after add", `
+ v  co1/vendor/co2/asm [co2/asm] < ["co1/pk1"]
+ vi co1/vendor/co2/cgo [co2/cgo] < ["co1/pk1"]
+ l  co1/pk1 < []
+`)
+	tree(g, "co1 after add", `
+/pk1/a.go
+/vendor/co2/asm/asm_amd64.s
+/vendor/co2/asm/stub.go
+/vendor/co2/cgo/a.c
+/vendor/co2/cgo/a.go
+/vendor/co2/cgo/a.h
+`)
+}
+
+func TestInternalImports(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co2/pk1/internal/x"),
+	)
+	g.Setup("co1/internal/y",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co1/pk2",
+		gt.File("a.go", "co1/internal/y"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "co2/pk1/internal/x"),
+	)
+	g.Setup("co2/pk1/internal/x",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.ModifyImport(pkg("co2/pk1/internal/x"), Add))
+	g.Check(c.Alter())
+
+	warning, err := c.InternalImports()
+	g.Check(err)
+	if len(warning) != 1 {
+		t.Fatalf("got %d warnings, want 1: %v", len(warning), warning)
+	}
+	want := Warning{
+		Code:    WarnInternalImport,
+		Path:    "co1/pk1",
+		Message: `imports "co2/pk1/internal/x" which is internal to "co2/pk1"`,
+	}
+	if warning[0] != want {
+		t.Fatalf("got %v, want %v", warning[0], want)
+	}
+}
+
+func TestCopyTransform(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Check(ioutil.WriteFile(g.Path("co2/pk1/README"), []byte("readme\n"), 0666))
+	g.In("co1")
+
+	const header = "// Vendored, do not edit.\n"
+	defer func() { copyTransforms = nil }()
+	RegisterCopyTransform("co2", func(content []byte) []byte {
+		return append([]byte(header), content...)
+	})
+
+	c := ctx(g)
+	g.Check(c.ModifyStatus(StatusGroup{
+		Status: []Status{{Location: LocationExternal}},
+	}, Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	read := func(p string) string {
+		b, err := ioutil.ReadFile(filepath.Join(g.Current(), "vendor", p))
+		g.Check(err)
+		return string(b)
+	}
+	if got := read("co2/pk1/a.go"); !strings.HasPrefix(got, header) {
+		t.Fatalf("transform not applied, got %q", got)
+	}
+	if got := read("co2/pk1/README"); got != "readme\n" {
+		t.Fatalf("non-go file changed, got %q", got)
+	}
+	if got := read("co3/pk1/a.go"); strings.HasPrefix(got, header) {
+		t.Fatalf("transform applied outside prefix, got %q", got)
+	}
+
+	// The checksum is of the transformed files.
+	c = ctx(g)
+	outOfDate, err := c.VerifyVendor()
+	g.Check(err)
+	if len(outOfDate) != 0 {
+		t.Fatalf("unexpected out of date packages %v", outOfDate)
+	}
+}
+
+func TestVersionOf(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "co3/pk1/sub"),
+	)
+	g.Setup("co3/pk1/sub",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.ModifyImport(pkg("co3/pk1"), Add, IncludeTree))
+	g.Check(c.Alter())
+	for _, vp := range c.VendorFile.Package {
+		vp.Revision = "abc123"
+		if vp.Path == "co2/pk1" {
+			vp.Version = "v1.2"
+			vp.RevisionTime = "2016-03-04T05:06:07Z"
+		}
+	}
+
+	version, tm, ok := c.VersionOf("co1/vendor/co2/pk1")
+	if !ok || version != "v1.2" || !tm.Equal(time.Date(2016, 3, 4, 5, 6, 7, 0, time.UTC)) {
+		t.Fatalf("co2/pk1: got %q %v %v", version, tm, ok)
+	}
+	version, tm, ok = c.VersionOf("co3/pk1/sub")
+	if !ok || version != "abc123" || !tm.IsZero() {
+		t.Fatalf("co3/pk1/sub: got %q %v %v", version, tm, ok)
+	}
+	if _, _, ok = c.VersionOf("co4/pk1"); ok {
+		t.Fatal("co4/pk1: expected not found")
+	}
+}
+
+func TestRemoveMissing(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyStatus(StatusGroup{
+		Status: []Status{{Location: LocationExternal}},
+	}, Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+	g.Check(os.RemoveAll(g.Path("co2/pk1")))
+
+	c = ctx(g)
+	g.Check(c.ModifyStatus(StatusGroup{
+		Status: []Status{{Location: LocationVendor}},
+	}, Remove))
+	warning, err := c.AlterWarn()
+	g.Check(err)
+	if len(warning) != 1 || warning[0].Code != WarnRemovedMissing || warning[0].Path != "co2/pk1" {
+		t.Fatalf("unexpected warnings %v", warning)
+	}
+}
+
+func TestAddLocalPackage(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co1/pk2"),
+	)
+	g.Setup("co1/pk2",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	err := c.ModifyImport(pkg("co1/pk2"), Add)
+	want := ErrLocalPackage{ImportPath: "co1/pk2", Root: "co1"}
+	if err != want {
+		t.Fatalf("got error %v, want %v", err, want)
+	}
+
+	// Local packages selected by status are skipped.
+	g.Check(c.ModifyStatus(StatusGroup{
+		Status: []Status{{Location: LocationLocal}},
+	}, Add))
+	g.Check(c.Alter())
+	if _, err := os.Stat(g.Path("co1/vendor/co1")); !os.IsNotExist(err) {
+		t.Fatal("local package vendored")
+	}
+}
+
+func TestPreserveDirTime(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	mtime := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	g.Check(os.Chtimes(g.Path("co2/pk1"), mtime, mtime))
+	g.In("co1")
+
+	c := ctx(g)
+	c.PreserveDirTime = true
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+
+	fi, err := os.Stat(filepath.Join(g.Current(), "vendor", "co2", "pk1"))
+	g.Check(err)
+	if !fi.ModTime().Equal(mtime) {
+		t.Fatalf("got dir time %v, want %v", fi.ModTime(), mtime)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co4/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyStatus(StatusGroup{
+		Status: []Status{{Location: LocationExternal}},
+	}, Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co4/pk1"),
+	)
+	c = ctx(g)
+	added, removed, err := c.Reconcile()
+	g.Check(err)
+	if got := strings.Join(added, " "); got != "co4/pk1" {
+		t.Fatalf("got added %q", got)
+	}
+	if got := strings.Join(removed, " "); got != "co3/pk1" {
+		t.Fatalf("got removed %q", got)
+	}
+	c = ctx(g)
+	list(g, c, "reconciled", `
+ v  co1/vendor/co2/pk1 [co2/pk1] < ["co1/pk1"]
+ v  co1/vendor/co4/pk1 [co4/pk1] < ["co1/pk1"]
+ l  co1/pk1 < []
+ s  strings < ["co1/vendor/co2/pk1" "co1/vendor/co4/pk1"]
+`)
+}
+
+func TestNestedVendorShadow(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1"),
+	)
+	g.Setup("co1/vendor/co2/pk1",
+		gt.File("a.go", "co3/pk1"),
+	)
+	g.Setup("co1/vendor/co2/pk1/vendor/co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co1/vendor/co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Check(ioutil.WriteFile(g.Path("co1/vendor/vendor.json"), []byte(`{"package":[{"path":"co2/pk1"},{"path":"co3/pk1"}]}`), 0666))
+	g.In("co1")
+
+	// The vendored package uses its own vendor folder before the project one.
+	c := ctx(g)
+	list(g, c, "nested", `
+ v  co1/vendor/co2/pk1 [co2/pk1] < ["co1/pk1"]
+ v  co1/vendor/co2/pk1/vendor/co3/pk1 [co3/pk1] < ["co1/vendor/co2/pk1"]
+ v  co1/vendor/co3/pk1 [co3/pk1] < ["co1/pk1"]
+ l  co1/pk1 < []
+ s  strings < ["co1/vendor/co2/pk1/vendor/co3/pk1" "co1/vendor/co3/pk1"]
+`)
+}
+
+func TestSetVendorFileName(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	if err := c.SetVendorFileName("sub/manifest.json"); err == nil {
+		t.Fatal("expected error for name with a directory")
+	}
+	g.Check(c.SetVendorFileName("manifest.json"))
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	if _, err := os.Stat(filepath.Join(g.Current(), relVendorFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected no vendor.json, got %v", err)
+	}
+	c = ctx(g)
+	g.Check(c.SetVendorFileName("manifest.json"))
+	if c.VendorFilePackagePath("co2/pk1") == nil {
+		t.Fatal("expected co2/pk1 in manifest.json")
+	}
+}
+
+func TestUntrackedVendor(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1"),
+	)
+	g.Setup("co1/vendor/co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co1/vendor/co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Check(ioutil.WriteFile(g.Path("co1/vendor/vendor.json"), []byte(`{"package":[{"path":"co2/pk1"}]}`), 0666))
+	g.In("co1")
+	c := ctx(g)
+
+	warning, err := c.UntrackedVendor()
+	g.Check(err)
+	want := []Warning{{
+		Code:    WarnUntrackedVendor,
+		Path:    "co1/vendor/co3/pk1",
+		Message: "in the vendor folder but not in the vendor file",
+	}}
+	if len(warning) != len(want) || warning[0] != want[0] {
+		t.Fatalf("got %v, want %v", warning, want)
+	}
+}
+
+func TestWalkPackages(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co1/pk1/sub"),
+	)
+	g.Setup("co1/pk1/sub",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co1/pk2",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+
+	var got []string
+	g.Check(c.WalkPackages(func(pkg *Package) error {
+		got = append(got, pkg.Local)
+		return nil
+	}))
+	if s := strings.Join(got, " "); s != "co1/pk1/sub co1/pk1 co1/pk2" {
+		t.Fatalf("got %q", s)
+	}
+
+	got = nil
+	g.Check(c.WalkPackages(func(pkg *Package) error {
+		got = append(got, pkg.Local)
+		return ErrStopWalk
+	}))
+	if len(got) != 1 {
+		t.Fatalf("expected walk to stop after one package, got %q", got)
+	}
+
+	// The project is loaded again after a walk.
+	list(g, c, "after walk", `
+ l  co1/pk1 < []
+ l  co1/pk1/sub < ["co1/pk1"]
+ l  co1/pk2 < []
+ s  strings < ["co1/pk1/sub" "co1/pk2"]
+`)
+}
+
+func TestAmbiguousImports(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	second, err := ioutil.TempDir("", "govendor-gopath")
+	g.Check(err)
+	defer os.RemoveAll(second)
+	dup := filepath.Join(second, "src", "co2", "pk1")
+	g.Check(os.MkdirAll(dup, 0777))
+	g.Check(ioutil.WriteFile(filepath.Join(dup, "a.go"), []byte("package pk1\n"), 0666))
+	first := os.Getenv("GOPATH")
+	os.Setenv("GOPATH", first+string(filepath.ListSeparator)+second)
+	defer os.Setenv("GOPATH", first)
+	g.In("co1")
+	c := ctx(g)
+
+	got, err := c.AmbiguousImports()
+	g.Check(err)
+	if len(got) != 1 || len(got["co2/pk1"]) != 2 {
+		t.Fatalf("got %q", got)
+	}
+	if want := g.Path("co2/pk1"); got["co2/pk1"][0] != want {
+		t.Fatalf("got first location %q, want %q", got["co2/pk1"][0], want)
+	}
+}
+
+func TestInternalPrefix(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	if got := c.InternalPrefix(); got != "co1/vendor" {
+		t.Fatalf("got %q", got)
+	}
+	c, err := NewContext(g.Current(), filepath.Join("internal", "vendor.json"), "internal", false)
+	g.Check(err)
+	if got := c.InternalPrefix(); got != "co1/internal" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestMixedImports(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1"),
+	)
+	g.Setup("co1/pk2",
+		gt.File("a.go", "co1/vendor/co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.ModifyImport(pkg("co3/pk1"), Add))
+	g.Check(c.Alter())
+
+	warning, err := c.MixedImports()
+	g.Check(err)
+	want := []Warning{
+		{
+			Code:    WarnMixedImport,
+			Path:    filepath.Join(g.Path("co1/pk1"), "a.go"),
+			Message: `imports "co2/pk1" while other files import "co1/vendor/co2/pk1"`,
+		},
+		{
+			Code:    WarnMixedImport,
+			Path:    filepath.Join(g.Path("co1/pk2"), "a.go"),
+			Message: `imports "co1/vendor/co2/pk1" while other files import "co2/pk1"`,
+		},
+	}
+	if len(warning) != len(want) || warning[0] != want[0] || warning[1] != want[1] {
+		t.Fatalf("got %v, want %v", warning, want)
+	}
+}
+
+func TestAddDir(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co9/pk1"),
+	)
+	src, err := ioutil.TempDir("", "govendor-adddir")
+	g.Check(err)
+	defer os.RemoveAll(src)
+	g.Check(ioutil.WriteFile(filepath.Join(src, "a.go"), []byte("package pk1\n\nimport \"strings\"\n"), 0666))
+	g.In("co1")
+	c := ctx(g)
+
+	if err := c.AddDir(src, "co1/pk2"); err == nil {
+		t.Fatal("expected error for project import path")
+	}
+	g.Check(c.AddDir(src, "co9/pk1"))
+	if err := c.AddDir(src, "co9/pk1"); err == nil {
+		t.Fatal("expected error when already vendored")
+	}
+
+	c = ctx(g)
+	list(g, c, "added dir", `
+ v  co1/vendor/co9/pk1 [co9/pk1] < ["co1/pk1"]
+ l  co1/pk1 < []
+ s  strings < ["co1/vendor/co9/pk1"]
+`)
+	if vp := c.VendorFilePackagePath("co9/pk1"); vp == nil || len(vp.ChecksumSHA1) == 0 {
+		t.Fatalf("expected co9/pk1 with a checksum in the vendor file, got %v", vp)
+	}
+}
+
+func TestDependencyOrder(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "co3/pk1"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "co4/pk1"),
+	)
+	g.Setup("co4/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+
+	buf := &bytes.Buffer{}
+	c := ctx(g)
+	c.Logger = buf
+	c.Verbosity = 1
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.ModifyImport(pkg("co3/pk1"), Add))
+	g.Check(c.ModifyImport(pkg("co4/pk1"), Add))
+	g.Check(c.Alter())
+	want := "copying co4/pk1 (1 files)\ncopying co3/pk1 (1 files)\ncopying co2/pk1 (1 files)\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("got output %q, want %q", got, want)
+	}
+}
+
+func TestRevisionMismatch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git command not available")
+	}
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	git := func(args ...string) {
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)
+		cmd := exec.Command("git", args...)
+		cmd.Dir = g.Path("co2/pk1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %q: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+
+	warning, err := c.RevisionMismatch()
+	g.Check(err)
+	if len(warning) != 0 {
+		t.Fatalf("unexpected warnings %v", warning)
+	}
+
+	git("commit", "-q", "--allow-empty", "-m", "second")
+	warning, err = c.RevisionMismatch()
+	g.Check(err)
+	if len(warning) != 1 || warning[0].Code != WarnRevisionMismatch || warning[0].Path != "co2/pk1" {
+		t.Fatalf("unexpected warnings %v", warning)
+	}
+}
+
+func TestDirtyOtherPackage(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git command not available")
+	}
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co2/pk2",
+		gt.File("a.go", "strings"),
+	)
+	git := func(args ...string) {
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)
+		cmd := exec.Command("git", args...)
+		cmd.Dir = g.Path("co2")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %q: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+	g.Check(ioutil.WriteFile(g.Path("co2/pk2/build.out"), []byte("x"), 0666))
+
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	if vp := c.VendorFilePackagePath("co2/pk1"); vp == nil || len(vp.Revision) == 0 {
+		t.Fatalf("package not added at a revision: %v", vp)
+	}
+
+	g.Check(ioutil.WriteFile(g.Path("co2/pk1/build.out"), []byte("x"), 0666))
+	c = ctx(g)
+	err := c.ModifyImport(pkg("co2/pk1"), Update)
+	if _, is := err.(ErrDirtyPackage); !is {
+		t.Fatalf("expected dirty package error, got %v", err)
+	}
+}
+
+func TestVendorFileOrder(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1"),
+	)
+	g.Setup("co1/vendor/co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co1/vendor/co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Check(ioutil.WriteFile(g.Path("co1/vendor/vendor.json"), []byte(`{"package":[{"path":"co3/pk1"},{"path":"co2/pk1"}]}`), 0666))
+	g.In("co1")
+	c := ctx(g)
+
+	list, err := c.Status()
+	g.Check(err)
+	var got []string
+	for _, item := range c.VendorFileOrder(list) {
+		got = append(got, item.Local)
+	}
+	want := "co1/vendor/co3/pk1 co1/vendor/co2/pk1 co1/pk1 strings"
+	if s := strings.Join(got, " "); s != want {
+		t.Fatalf("got %q, want %q", s, want)
+	}
+	// The status list itself is not changed.
+	if list[0].Local != "co1/vendor/co2/pk1" {
+		t.Fatalf("status list reordered, got %q first", list[0].Local)
+	}
+}
+
+func TestGopathGoroot(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "strings"),
+	)
+	out, err := exec.Command("go", "env", "GOROOT").Output()
+	g.Check(err)
+	goroot := strings.TrimSpace(string(out))
+	first := os.Getenv("GOPATH")
+	os.Setenv("GOPATH", first+string(filepath.ListSeparator)+goroot)
+	defer os.Setenv("GOPATH", first)
+
+	_, err = NewContextRoot(g.Path("co1"))
+	if _, is := err.(ErrGopathGoroot); !is {
+		t.Fatalf("got error %v, want ErrGopathGoroot", err)
+	}
+	os.Setenv("GOPATH", first)
+	if _, err = NewContextRoot(g.Path("co1")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInfo(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+
+	info := c.Info()
+	if info.RootDir != g.Path("co1") || info.RootImportPath != "co1" || info.VendorFolder != "vendor" {
+		t.Fatalf("got %+v", info)
+	}
+	if info.Goroot != c.Goroot || info.Gopath != c.RootGopath {
+		t.Fatalf("got %+v", info)
+	}
+	if info.VendorPackages != 1 {
+		t.Fatalf("got %d vendor packages, want 1", info.VendorPackages)
+	}
+}
+
+func TestRewriteVendored(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c, err := NewContext(g.Current(), filepath.Join("internal", "vendor.json"), "internal", true)
+	g.Check(err)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	// Undo the rewrite as if it was interrupted.
+	fp := g.Path("co1/pk1/a.go")
+	src, err := ioutil.ReadFile(fp)
+	g.Check(err)
+	g.Check(ioutil.WriteFile(fp, bytes.Replace(src, []byte(`"co1/internal/co2/pk1"`), []byte(`"co2/pk1"`), 1), 0666))
+
+	c, err = NewContext(g.Current(), filepath.Join("internal", "vendor.json"), "internal", true)
+	g.Check(err)
+	if _, is := c.Rewrite("co3/pk1").(ErrNotVendored); !is {
+		t.Fatal("expected ErrNotVendored")
+	}
+	g.Check(c.Rewrite("co2/pk1"))
+	src, err = ioutil.ReadFile(fp)
+	g.Check(err)
+	if !bytes.Contains(src, []byte(`"co1/internal/co2/pk1"`)) {
+		t.Fatalf("import not rewritten:\n%s", src)
+	}
+}
+
+func TestVersionedImportPath(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	// The last element of a gopkg.in path is not the package name and the
+	// package has an import comment that must be removed.
+	g.Setup("co1/pk1")
+	g.Setup("gopkg.in/yaml.v2")
+	g.Check(ioutil.WriteFile(g.Path("co1/pk1/a.go"), []byte("package pk1\n\nimport \"gopkg.in/yaml.v2\"\n\nvar _ = yaml.Marshal\n"), 0666))
+	g.Check(ioutil.WriteFile(g.Path("gopkg.in/yaml.v2/a.go"), []byte("package yaml // import \"gopkg.in/yaml.v2\"\n\nfunc Marshal() {}\n"), 0666))
+	g.In("co1")
+	c, err := NewContext(g.Current(), filepath.Join("internal", "vendor.json"), "internal", true)
+	g.Check(err)
+	g.Check(c.ModifyImport(pkg("gopkg.in/yaml.v2"), Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	if _, err = os.Stat(g.Path("co1/internal/gopkg.in/yaml.v2/a.go")); err != nil {
+		t.Fatal(err)
+	}
+	src, err := ioutil.ReadFile(g.Path("co1/pk1/a.go"))
+	g.Check(err)
+	if !bytes.Contains(src, []byte(`import "co1/internal/gopkg.in/yaml.v2"`)) {
+		t.Fatalf("import not rewritten:\n%s", src)
+	}
+	cmd := exec.Command("go", "build", "./...")
+	cmd.Dir = g.Current()
+	cmd.Env = append(os.Environ(), "GO111MODULE=off")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("build failed: %v\n%s", err, out)
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co1/pk2",
+		gt.File("a.go", "strings"),
+	)
+	broken := []byte("package pk1\n\nimport (\n\t\"strings\"\n")
+	g.Check(ioutil.WriteFile(g.Path("co1/pk1/b.go"), broken, 0666))
+	g.Check(ioutil.WriteFile(g.Path("co1/pk2/b.go"), broken, 0666))
+	g.In("co1")
+
+	// Parse errors are ignored by default.
+	c := ctx(g)
+	_, err := c.Status()
+	g.Check(err)
+
+	c = ctx(g)
+	c.CollectErrors = true
+	_, err = c.Status()
+	le, is := err.(LoadErrors)
+	if !is || len(le) != 2 {
+		t.Fatalf("got error %v, want two load errors", err)
+	}
+	if c.Package["co1/pk2"] == nil {
+		t.Fatal("loading stopped at the first error")
+	}
+}
+
+func TestVendorImportsProject(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co1/pk2",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co1/vendor/co2/pk1",
+		gt.File("a.go", "co1/pk2", "co1/vendor/co3/pk1"),
+		gt.File("b.go", "strings"),
+	)
+	g.Setup("co1/vendor/co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+
+	got, err := c.VendorImportsProject()
+	g.Check(err)
+	if len(got) != 1 {
+		t.Fatalf("got %d warnings, want 1: %v", len(got), got)
+	}
+	if got[0].Code != WarnVendorImportsProject || got[0].Path != g.Path("co1/vendor/co2/pk1/a.go") {
+		t.Fatalf("got %v", got[0])
+	}
+}
+
+func BenchmarkAddRewrite(b *testing.B) {
+	base, err := ioutil.TempDir("", "govendor-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+	gopath := os.Getenv("GOPATH")
+	os.Setenv("GOPATH", base)
+	defer os.Setenv("GOPATH", gopath)
+
+	write := func(name, src string) {
+		p := filepath.Join(base, "src", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			b.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(src), 0666); err != nil {
+			b.Fatal(err)
+		}
+	}
+	const project = "package pk1\n\nimport _ \"co2/pk1\"\n"
+	write("co2/pk2/a.go", "package pk2\n")
+	for i := 0; i < 500; i++ {
+		write(fmt.Sprintf("co2/pk1/a%d.go", i), fmt.Sprintf("package pk1 // import \"co2/pk1\"\n\nimport _ \"co2/pk2\"\n\nfunc F%d() {}\n", i))
+	}
+	root := filepath.Join(base, "src", "co1")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		write("co1/pk1/a.go", project)
+		if err := os.RemoveAll(filepath.Join(root, "internal")); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+
+		c, err := NewContext(root, filepath.Join("internal", "vendor.json"), "internal", true)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, p := range []string{"co2/pk1", "co2/pk2"} {
+			if err := c.ModifyImport(&pkgspec.Pkg{Path: p}, Add); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := c.Alter(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestMissingImportedByFile(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+		gt.File("b.go", "strings"),
+	)
+	g.Setup("co1/pk2",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.In("co1")
+	c := ctx(g)
+
+	list, err := c.Status()
+	g.Check(err)
+	for _, item := range list {
+		if item.Pkg.Path != "co2/pk1" {
+			if len(item.ImportedByFile) != 0 {
+				t.Fatalf("%s has importing files %q", item.Pkg.Path, item.ImportedByFile)
+			}
+			continue
+		}
+		if item.Status.Presence != PresenceMissing {
+			t.Fatalf("got status %v", item.Status)
+		}
+		want := []string{g.Path("co1/pk1/a.go"), g.Path("co1/pk2/a.go")}
+		if !reflect.DeepEqual(item.ImportedByFile, want) {
+			t.Fatalf("got %q, want %q", item.ImportedByFile, want)
+		}
+		return
+	}
+	t.Fatal("missing package not listed")
+}
+
+func TestWorkers(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "strings"),
+		gt.File("b.go", "co1/pk2"),
+	)
+	g.Setup("co1/pk2",
+		gt.File("a.go", "co2/pk2"),
+	)
+	g.Setup("co1/vendor/co2/pk1",
+		gt.File("a.go", "bytes"),
+	)
+	g.Setup("co2/pk2",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+
+	var want string
+	for _, workers := range []int{1, 0, 16} {
+		c := ctx(g)
+		c.Workers = workers
+		list, err := c.Status()
+		g.Check(err)
+		got := fmt.Sprint(list)
+		if workers == 1 {
+			want = got
+			continue
+		}
+		if got != want {
+			t.Fatalf("with %d workers got\n%s\nwant\n%s", workers, got, want)
+		}
+	}
+}
+
+func TestPackagesMissingLicense(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1", "co4/pk1"),
+	)
+	g.Setup("co1/vendor/co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co1/vendor/co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co1/vendor/co4/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Check(ioutil.WriteFile(g.Path("co1/vendor/co2/LICENSE"), []byte("license"), 0666))
+	g.Check(ioutil.WriteFile(g.Path("co1/vendor/co3/pk1/COPYING"), []byte("license"), 0666))
+	// Not the license of any package.
+	g.Check(ioutil.WriteFile(g.Path("co1/vendor/LICENSE"), []byte("license"), 0666))
+	g.Check(ioutil.WriteFile(g.Path("co1/vendor/vendor.json"), []byte(`{"package":[{"path":"co2/pk1"},{"path":"co3/pk1"},{"path":"co4/pk1"},{"path":"co5/pk1"}]}`), 0666))
+	g.In("co1")
+	c := ctx(g)
+
+	got, err := c.PackagesMissingLicense()
+	g.Check(err)
+	if want := []string{"co4/pk1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestRepoint(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.FilePkgBuild("a.go", "pk1", "", "bytes"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	// The upstream moved from co2/pk1 to co3/pk1.
+	g.Check(os.RemoveAll(g.Path("co2")))
+	c = ctx(g)
+	if _, is := c.Repoint("co4/pk1", "co3/pk1").(ErrNotVendored); !is {
+		t.Fatal("expected ErrNotVendored")
+	}
+	g.Check(c.Repoint("co2/pk1", "co3/pk1"))
+
+	vp := c.VendorFilePackagePath("co2/pk1")
+	if vp == nil || vp.Origin != "co3/pk1" {
+		t.Fatalf("got vendor file package %+v", vp)
+	}
+	src, err := ioutil.ReadFile(g.Path("co1/vendor/co2/pk1/a.go"))
+	g.Check(err)
+	if !bytes.Contains(src, []byte("bytes")) {
+		t.Fatalf("package not copied from the new origin:\n%s", src)
+	}
+	src, err = ioutil.ReadFile(g.Path("co1/pk1/a.go"))
+	g.Check(err)
+	if !bytes.Contains(src, []byte("co2/pk1")) {
+		t.Fatalf("project import changed:\n%s", src)
+	}
+}
+
+func TestWriteDot(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1", "co4/pk1", "strings"),
+	)
+	g.Setup("co1/vendor/co2/pk1",
+		gt.File("a.go", "bytes"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+
+	const want = `digraph govendor {
+	"co1/vendor/co2/pk1" [shape=box, style=filled, fillcolor=lightblue];
+	"co3/pk1" [shape=box, style=dashed];
+	"co1/pk1" [shape=ellipse];
+	"co4/pk1" [shape=box, color=red, fontcolor=red];
+	"co1/pk1" -> "co1/vendor/co2/pk1";
+	"co1/pk1" -> "co3/pk1";
+	"co1/pk1" -> "co4/pk1";
+}
+`
+	buf := &bytes.Buffer{}
+	g.Check(c.WriteDot(buf, false))
+	if buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	g.Check(c.WriteDot(buf, true))
+	if got := buf.String(); !strings.Contains(got, `"co3/pk1" -> "strings";`) || !strings.Contains(got, `"bytes" [`) {
+		t.Fatalf("standard packages not included:\n%s", got)
+	}
+}
+
+func TestCanVendor(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co4/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co5/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	c.IgnoreBuildAndPackage("co5/")
+	ops := len(c.Operation)
+
+	list := []struct {
+		Path   string
+		OK     bool
+		Reason string
+	}{
+		{"co3/pk1", true, ""},
+		{"co4/pk1", true, ""},
+		{"co2/pk1", false, "package is already vendored"},
+		{"strings", false, "standard library package"},
+		{"C", false, "standard library package"},
+		{"co1/pk1", false, `package is in the project "co1"`},
+		{"co5/pk1", false, `package is excluded by the ignore rule "co5/"`},
+		{"co6/pk1", false, "package not found in GOPATH"},
+		{"", false, "import path is empty"},
+	}
+	for _, item := range list {
+		ok, reason, err := c.CanVendor(item.Path)
+		g.Check(err)
+		if ok != item.OK || reason != item.Reason {
+			t.Errorf("for %q got %t %q, want %t %q", item.Path, ok, reason, item.OK, item.Reason)
+		}
+	}
+	// Nothing was changed.
+	if len(c.Operation) != ops {
+		t.Fatalf("got %d operations, want %d", len(c.Operation), ops)
+	}
+}
+
+func TestGopathShadow(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co4/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.ModifyImport(pkg("co4/pk1"), Add))
+	g.Check(c.Alter())
+
+	// co3/pk1 is not vendored, so it builds co2/pk1 from GOPATH.
+	got, err := c.GopathShadow()
+	g.Check(err)
+	if len(got) != 1 || got[0].Code != WarnGopathShadow || got[0].Path != "co2/pk1" {
+		t.Fatalf("got %v", got)
+	}
+	if !strings.Contains(got[0].Message, "co3/pk1") {
+		t.Fatalf("importer not given: %v", got[0])
+	}
+}
+
+func TestBackupRewrite(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co2/pk2"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co2/pk2",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	fp := g.Path("co1/pk1/a.go")
+	before, err := ioutil.ReadFile(fp)
+	g.Check(err)
+	// A backup made by hand is not removed.
+	g.Check(ioutil.WriteFile(fp+".bak", before, 0600))
+
+	c, err := NewContext(g.Current(), filepath.Join("internal", "vendor.json"), "internal", true)
+	g.Check(err)
+	c.BackupRewrite = true
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	// A second rewrite keeps the backup of the original.
+	g.Check(c.ModifyImport(pkg("co2/pk2"), Add))
+	g.Check(c.Alter())
+
+	backup, err := ioutil.ReadFile(fp + ".govendor.bak")
+	g.Check(err)
+	if !bytes.Equal(backup, before) {
+		t.Fatalf("got backup:\n%s\nwant:\n%s", backup, before)
+	}
+
+	removed, err := c.RemoveRewriteBackups()
+	g.Check(err)
+	if len(removed) != 1 || removed[0] != fp+".govendor.bak" {
+		t.Fatalf("got removed %q", removed)
+	}
+	if _, err = os.Stat(fp + ".govendor.bak"); !os.IsNotExist(err) {
+		t.Fatal("backup not removed")
+	}
+	if _, err = os.Stat(fp + ".bak"); err != nil {
+		t.Fatal("own backup removed:", err)
+	}
+}
+
+func TestCopyInactiveFiles(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co2/pk1/sub",
+		gt.File("a.go", "strings"),
+		gt.File("b_plan9.go", "bytes"),
+		gt.FileBuild("c_windows.go", "!windows", "bytes"),
+		gt.FilePkgBuild("gen.go", "main", "ignore", "fmt"),
+		gt.FileBuild("d.go", "appengine", "bytes"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	c.IgnoreBuildAndPackage("appengine")
+	g.Check(c.ModifyImport(pkg("co2/pk1/^"), Add))
+	g.Check(c.Alter())
+
+	for _, name := range []string{"a.go", "b_plan9.go", "c_windows.go", "gen.go"} {
+		if _, err := os.Stat(g.Path("co1/vendor/co2/pk1/sub/" + name)); err != nil {
+			t.Errorf("file %q not copied: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(g.Path("co1/vendor/co2/pk1/sub/d.go")); !os.IsNotExist(err) {
+		t.Errorf("ignored file d.go copied")
+	}
+}
+
+func TestClassifyHypothetical(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co1/pk2",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co1/vendor/co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+
+	list := []struct {
+		path   string
+		status Status
+	}{
+		{"strings", Status{Type: TypePackage, Location: LocationStandard, Presence: PresenceFound}},
+		{"co1/pk2", Status{Type: TypePackage, Location: LocationLocal, Presence: PresenceFound}},
+		{"co3/pk1", Status{Type: TypePackage, Location: LocationVendor, Presence: PresenceFound}},
+		{"co2/pk1", Status{Type: TypePackage, Location: LocationExternal, Presence: PresenceFound}},
+		{"co4/pk1", Status{Type: TypePackage, Location: LocationNotFound, Presence: PresenceMissing}},
+	}
+	for _, item := range list {
+		st, err := c.ClassifyHypothetical(item.path)
+		g.Check(err)
+		if st != item.status {
+			t.Errorf("%s: got %v, want %v", item.path, st, item.status)
+		}
+	}
+	if len(c.Package) != 0 {
+		t.Errorf("packages loaded: %d", len(c.Package))
+	}
+}
+
+func TestMaxFileSize(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Check(ioutil.WriteFile(g.Path("co2/pk1/data.bin"), bytes.Repeat([]byte{'x'}, 4096), 0600))
+	g.Check(ioutil.WriteFile(g.Path("co2/pk1/gen.go"), append([]byte("package pk1\n\n"), bytes.Repeat([]byte("// generated\n"), 400)...), 0600))
+	g.In("co1")
+	c := ctx(g)
+	c.MaxFileSize = 1024
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	warning, err := c.AlterWarn()
+	g.Check(err)
+	g.Check(c.WriteVendorFile())
+
+	if _, err := os.Stat(g.Path("co1/vendor/co2/pk1/a.go")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(g.Path("co1/vendor/co2/pk1/gen.go")); err != nil {
+		t.Fatal("large Go file not copied:", err)
+	}
+	if _, err := os.Stat(g.Path("co1/vendor/co2/pk1/data.bin")); !os.IsNotExist(err) {
+		t.Fatal("large file copied")
+	}
+	if len(warning) != 1 || warning[0].Code != WarnFileTooLarge || warning[0].Path != g.Path("co2/pk1/data.bin") {
+		t.Fatalf("got warnings %v", warning)
+	}
+
+	// The saved limit is used when the package is updated without one.
+	c = ctx(g)
+	if vp := c.VendorFilePackagePath("co2/pk1"); vp == nil || vp.MaxFileSize != 1024 {
+		t.Fatalf("limit not saved in vendor file: %v", vp)
+	}
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Update))
+	warning, err = c.AlterWarn()
+	g.Check(err)
+	if _, err := os.Stat(g.Path("co1/vendor/co2/pk1/data.bin")); !os.IsNotExist(err) {
+		t.Fatal("large file copied on update")
+	}
+	if len(warning) != 1 || warning[0].Code != WarnFileTooLarge {
+		t.Fatalf("got warnings on update %v", warning)
+	}
+}
+
+func TestFindByVendorLocal(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+
+	if vp, found := c.FindByVendor("co2/pk1"); !found || vp.Path != "co2/pk1" {
+		t.Errorf("vendor path not found: %v", vp)
+	}
+	if vp, found := c.FindByLocal("co1/vendor/co2/pk1"); !found || vp.Path != "co2/pk1" {
+		t.Errorf("local path not found: %v", vp)
+	}
+	if _, found := c.FindByLocal("co2/pk1"); found {
+		t.Error("vendor path found as local path")
+	}
+	if _, found := c.FindByVendor("co1/vendor/co2/pk1"); found {
+		t.Error("local path found as vendor path")
+	}
+}
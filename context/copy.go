@@ -5,9 +5,11 @@
 package context
 
 import (
+	"bytes"
 	"fmt"
 	"hash"
 	"io"
+	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
@@ -18,6 +20,46 @@ import (
 	"github.com/pkg/errors"
 )
 
+// CopyTransform changes the contents of a Go file as it is copied into
+// the vendor folder.
+type CopyTransform func(content []byte) []byte
+
+type copyTransformEntry struct {
+	prefix    string
+	transform CopyTransform
+}
+
+var copyTransforms []copyTransformEntry
+
+// RegisterCopyTransform adds a transform that CopyPackage applies to each
+// Go file of packages with the import path prefix, such as to add a header
+// or strip a build tag. The prefix matches whole path elements. Transforms
+// are applied in the order registered, before any import rewriting. Not
+// safe to call concurrently with CopyPackage.
+func RegisterCopyTransform(prefix string, t CopyTransform) {
+	copyTransforms = append(copyTransforms, copyTransformEntry{prefix: strings.Trim(prefix, "/"), transform: t})
+}
+
+// copyTransform returns the transforms for a package combined, or nil
+// if there are none.
+func copyTransform(pkgPath string) CopyTransform {
+	var list []CopyTransform
+	for _, e := range copyTransforms {
+		if pkgPath == e.prefix || strings.HasPrefix(pkgPath, e.prefix+"/") {
+			list = append(list, e.transform)
+		}
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return func(content []byte) []byte {
+		for _, t := range list {
+			content = t(content)
+		}
+		return content
+	}
+}
+
 type fileInfoSort []os.FileInfo
 
 func (l fileInfoSort) Len() int {
@@ -37,17 +79,21 @@ func (l fileInfoSort) Swap(i, j int) {
 
 // CopyPackage copies the files from the srcPath to the destPath, destPath
 // folder and parents are are created if they don't already exist.
+// License files are searched for in parent folders up to lookRoot, an
+// empty lookRoot does not search.
 func (ctx *Context) CopyPackage(destPath, srcPath, lookRoot, pkgPath string, ignoreFiles []string, tree bool, h hash.Hash, beforeCopy func(deps []string) error) error {
 	if pathos.FileStringEquals(destPath, srcPath) {
 		return fmt.Errorf("Attempting to copy package to same location %q.", destPath)
 	}
-	err := os.MkdirAll(destPath, 0777)
+	// Deeply nested vendor paths may exceed MAX_PATH on windows.
+	longDestPath := pathos.LongPath(destPath)
+	err := os.MkdirAll(longDestPath, 0777)
 	if err != nil {
 		return err
 	}
 
 	// Ensure the dest is empty of files.
-	destDir, err := os.Open(destPath)
+	destDir, err := os.Open(longDestPath)
 	if err != nil {
 		return err
 	}
@@ -67,14 +113,14 @@ func (ctx *Context) CopyPackage(destPath, srcPath, lookRoot, pkgPath string, ign
 	for _, fi := range fl {
 		if fi.IsDir() {
 			if tree {
-				err = errors.Wrap(os.RemoveAll(filepath.Join(destPath, fi.Name())), "remove all existing tree entries")
+				err = errors.Wrap(os.RemoveAll(filepath.Join(longDestPath, fi.Name())), "remove all existing tree entries")
 				if err != nil {
 					return err
 				}
 			}
 			continue
 		}
-		err = errors.Wrap(os.Remove(filepath.Join(destPath, fi.Name())), "remove existing file")
+		err = errors.Wrap(os.Remove(filepath.Join(longDestPath, fi.Name())), "remove existing file")
 		if err != nil {
 			return err
 		}
@@ -97,6 +143,8 @@ func (ctx *Context) CopyPackage(destPath, srcPath, lookRoot, pkgPath string, ign
 		// Sort file list to present a stable hash.
 		sort.Sort(fileInfoSort(fl))
 	}
+	transform := copyTransform(pkgPath)
+	maxSize := ctx.maxFileSize(pkgPath)
 fileLoop:
 	for _, fi := range fl {
 		name := fi.Name()
@@ -116,6 +164,9 @@ fileLoop:
 					continue
 				}
 			}
+			if ctx.excludeDir(path.Join(pkgPath, name)) {
+				continue
+			}
 			nextDestPath := filepath.Join(destPath, name)
 			nextSrcPath := filepath.Join(srcPath, name)
 			var nextIgnoreFiles, deps []string
@@ -145,23 +196,91 @@ fileLoop:
 				continue fileLoop
 			}
 		}
+		if maxSize > 0 && fi.Size() > maxSize && !strings.HasSuffix(name, ".go") {
+			ctx.warn(WarnFileTooLarge, filepath.Join(srcPath, name), "not copied, %d bytes is over the limit of %d bytes", fi.Size(), maxSize)
+			continue
+		}
 		if h != nil {
 			h.Write([]byte(name))
 		}
+		var t CopyTransform
+		var rules map[string]string
+		if strings.HasSuffix(name, ".go") {
+			t = transform
+			rules = ctx.copyRewriteRule
+		}
 		err = copyFile(
-			filepath.Join(destPath, name),
+			filepath.Join(longDestPath, name),
 			filepath.Join(srcPath, name),
 			h,
+			t,
+			rules,
 		)
 		if err != nil {
 			return errors.Wrapf(err, "copyFile dest=%q src=%q", filepath.Join(destPath, name), filepath.Join(srcPath, name))
 		}
 	}
 
-	return errors.Wrapf(licenseCopy(lookRoot, srcPath, filepath.Join(ctx.RootDir, ctx.VendorFolder), pkgPath), "licenseCopy srcPath=%q", srcPath)
+	// Without a root there is no parent folder to search for licenses.
+	if len(lookRoot) != 0 {
+		err = licenseCopy(lookRoot, srcPath, filepath.Join(ctx.RootDir, ctx.VendorFolder), pkgPath)
+		if err != nil {
+			return errors.Wrapf(err, "licenseCopy srcPath=%q", srcPath)
+		}
+	}
+	if !ctx.PreserveDirTime {
+		return nil
+	}
+	// Set last, as writing files into the directory changes its time.
+	fi, err := os.Stat(srcPath)
+	if err != nil {
+		return err
+	}
+	return os.Chtimes(longDestPath, fi.ModTime(), fi.ModTime())
 }
 
-func copyFile(destPath, srcPath string, h hash.Hash) error {
+// excludeDir reports if the directory import path matches an exclude glob
+// of the vendored package that contains it.
+func (ctx *Context) excludeDir(dirPath string) bool {
+	if ctx.VendorFile == nil {
+		return false
+	}
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove || len(vp.Exclude) == 0 || !strings.HasPrefix(dirPath, vp.Path+"/") {
+			continue
+		}
+		rel := strings.TrimPrefix(dirPath, vp.Path+"/")
+		for _, glob := range vp.Exclude {
+			if ok, _ := path.Match(glob, rel); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// maxFileSize returns the file size limit saved for the vendored package
+// that contains the import path, or the context limit if that is set.
+func (ctx *Context) maxFileSize(pkgPath string) int64 {
+	if ctx.MaxFileSize > 0 || ctx.VendorFile == nil {
+		return ctx.MaxFileSize
+	}
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove || vp.MaxFileSize <= 0 {
+			continue
+		}
+		if vp.Path == pkgPath || (vp.Tree && strings.HasPrefix(pkgPath, vp.Path+"/")) {
+			return vp.MaxFileSize
+		}
+	}
+	return 0
+}
+
+// copyFile copies a single file, applying transform to the contents if set.
+// Imports matching rules are then rewritten and any import comment removed.
+// The hash is of the contents before imports are rewritten, the same as if
+// the file was rewritten after it was copied.
+func copyFile(destPath, srcPath string, h hash.Hash, transform CopyTransform, rules map[string]string) error {
 	ss, err := os.Stat(srcPath)
 	if err != nil {
 		return errors.Wrap(err, "copyFile Stat")
@@ -184,9 +303,31 @@ func copyFile(destPath, srcPath string, h hash.Hash) error {
 	}
 
 	r := io.Reader(src)
-
-	if h != nil {
-		r = io.TeeReader(src, h)
+	if transform != nil || len(rules) != 0 {
+		content, err := ioutil.ReadAll(src)
+		if err != nil {
+			dest.Close()
+			return errors.Wrap(err, "read")
+		}
+		if transform != nil {
+			content = transform(content)
+		}
+		if h != nil {
+			h.Write(content)
+		}
+		if len(rules) != 0 {
+			out, changed, err := rewriteSource(srcPath, content, rules, true)
+			if err != nil {
+				dest.Close()
+				return err
+			}
+			if changed {
+				content = out
+			}
+		}
+		r = bytes.NewReader(content)
+	} else if h != nil {
+		r = io.TeeReader(r, h)
 	}
 
 	_, err = io.Copy(dest, r)
@@ -0,0 +1,149 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ' for an unchanged line, '-' for removed, '+' for added.
+	text string
+}
+
+// unifiedDiff returns the changes from a to b in unified diff format, or
+// an empty string if they are equal. Both sides are labeled name.
+func unifiedDiff(name string, a, b []byte) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	// aLine[k] and bLine[k] are the number of lines of a and b before ops[k].
+	aLine := make([]int, len(ops)+1)
+	bLine := make([]int, len(ops)+1)
+	for k, op := range ops {
+		aLine[k+1], bLine[k+1] = aLine[k], bLine[k]
+		if op.kind != '+' {
+			aLine[k+1]++
+		}
+		if op.kind != '-' {
+			bLine[k+1]++
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "--- %s\n+++ %s\n", name, name)
+	for start := 0; start < len(ops); {
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		// Changes closer than twice the context share a hunk.
+		last := first
+		for k := first; k < len(ops) && k-last <= 2*diffContext+1; k++ {
+			if ops[k].kind != ' ' {
+				last = k
+			}
+		}
+		lo, hi := first-diffContext, last+1+diffContext
+		if lo < start {
+			lo = start
+		}
+		if hi > len(ops) {
+			hi = len(ops)
+		}
+		fmt.Fprintf(buf, "@@ -%s +%s @@\n", hunkRange(aLine[lo], aLine[hi]), hunkRange(bLine[lo], bLine[hi]))
+		for _, op := range ops[lo:hi] {
+			buf.WriteByte(op.kind)
+			buf.WriteString(op.text)
+			buf.WriteByte('\n')
+		}
+		start = hi
+	}
+	return buf.String()
+}
+
+// hunkRange formats the lines from, up to to, of a hunk header. An empty
+// range starts at the line before it.
+func hunkRange(from, to int) string {
+	if from == to {
+		return fmt.Sprintf("%d,0", from)
+	}
+	return fmt.Sprintf("%d,%d", from+1, to-from)
+}
+
+func splitLines(b []byte) []string {
+	s := strings.TrimSuffix(string(b), "\n")
+	if len(s) == 0 {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffLines returns the edit script from a to b. Lines common to the
+// start and end are not compared, so a change to the imports of a large
+// file stays cheap.
+func diffLines(a, b []string) []diffOp {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:pre] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	// lcs[i][j] is the length of the longest common subsequence of ma[i:] and mb[j:].
+	lcs := make([][]int, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			switch {
+			case ma[i] == mb[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			ops = append(ops, diffOp{' ', ma[i]})
+			i++
+			j++
+		case i == len(ma) || (j < len(mb) && lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, diffOp{'+', mb[j]})
+			j++
+		default:
+			ops = append(ops, diffOp{'-', ma[i]})
+			i++
+		}
+	}
+
+	for _, line := range a[len(a)-suf:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
@@ -0,0 +1,63 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// dotStyle is the Graphviz node style of each package location.
+var dotStyle = map[StatusLocation]string{
+	LocationLocal:    `shape=ellipse`,
+	LocationVendor:   `shape=box, style=filled, fillcolor=lightblue`,
+	LocationExternal: `shape=box, style=dashed`,
+	LocationStandard: `shape=ellipse, color=gray, fontcolor=gray`,
+}
+
+// WriteDot writes the import graph of the project and its dependencies in
+// the Graphviz DOT format, such as for "dot -Tsvg". Vendored packages are
+// filled boxes, external packages are dashed boxes and missing packages
+// are red. Standard library packages are left out unless std is set.
+func (ctx *Context) WriteDot(w io.Writer, std bool) error {
+	list, err := ctx.Status()
+	if err != nil {
+		return err
+	}
+	include := func(st Status) bool {
+		return std || st.Location != LocationStandard
+	}
+	var edges []string
+	buf := &bytes.Buffer{}
+	buf.WriteString("digraph govendor {\n")
+	for _, item := range list {
+		if !include(item.Status) {
+			continue
+		}
+		style := dotStyle[item.Status.Location]
+		if item.Status.Presence == PresenceMissing {
+			style = `shape=box, color=red, fontcolor=red`
+		}
+		if len(style) == 0 {
+			style = `shape=ellipse`
+		}
+		fmt.Fprintf(buf, "\t%q [%s];\n", item.Local, style)
+		for _, ref := range item.ImportedBy {
+			if !include(ref.Status) {
+				continue
+			}
+			edges = append(edges, fmt.Sprintf("\t%q -> %q;\n", ref.Local, item.Local))
+		}
+	}
+	sort.Strings(edges)
+	for _, e := range edges {
+		buf.WriteString(e)
+	}
+	buf.WriteString("}\n")
+	_, err = w.Write(buf.Bytes())
+	return err
+}
@@ -5,6 +5,7 @@
 package context
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 )
@@ -14,6 +15,12 @@ var (
 	ErrMissingGOROOT = errors.New("Unable to determine GOROOT.")
 	// ErrMissingGOPATH returns if no GOPATH was found.
 	ErrMissingGOPATH = errors.New("Missing GOPATH. Check your environment variable GOPATH.")
+	// ErrEmptyRootImportPath returns if the root import path is set to nothing.
+	ErrEmptyRootImportPath = errors.New("Root import path may not be empty.")
+	// ErrEmptyImportPath returns if a package import path is empty.
+	ErrEmptyImportPath = errors.New("Import path may not be empty.")
+	// ErrStopWalk may be returned from a WalkPackages function to stop the walk.
+	ErrStopWalk = errors.New("Stop walking packages.")
 )
 
 // ErrNotInGOPATH returns if not currently in the GOPATH.
@@ -25,6 +32,16 @@ func (err ErrNotInGOPATH) Error() string {
 	return fmt.Sprintf("Package %q not a go package or not in GOPATH.", err.Missing)
 }
 
+// ErrGopathGoroot returns if a GOPATH entry overlaps GOROOT.
+type ErrGopathGoroot struct {
+	Gopath string
+	Goroot string
+}
+
+func (err ErrGopathGoroot) Error() string {
+	return fmt.Sprintf("GOPATH %q overlaps GOROOT %q. Standard packages cannot be told apart from other packages; set GOPATH to a directory outside of GOROOT.", err.Gopath, err.Goroot)
+}
+
 // ErrDirtyPackage returns if package is in dirty version control.
 type ErrDirtyPackage struct {
 	ImportPath string
@@ -43,6 +60,18 @@ func (err ErrPackageExists) Error() string {
 	return fmt.Sprintf("Package %q already in vendor.", err.Package)
 }
 
+// ErrLocalPackage returns if a package in the project is added to the
+// vendor folder. A package is in the project if its import path is under
+// the project root import path.
+type ErrLocalPackage struct {
+	ImportPath string
+	Root       string
+}
+
+func (err ErrLocalPackage) Error() string {
+	return fmt.Sprintf("Package %q is in the project %q and cannot be vendored. Move it out of the project or set a different root import path.", err.ImportPath, err.Root)
+}
+
 // ErrMissingVendorFile returns if package already exists.
 type ErrMissingVendorFile struct {
 	Path string
@@ -52,6 +81,39 @@ func (err ErrMissingVendorFile) Error() string {
 	return fmt.Sprintf("Vendor file at %q not found.", err.Path)
 }
 
+// ErrVendorFileName returns if a vendor file name is empty or contains
+// a path separator.
+type ErrVendorFileName struct {
+	Name string
+}
+
+func (err ErrVendorFileName) Error() string {
+	return fmt.Sprintf("Vendor file name %q must be a file name without a directory.", err.Name)
+}
+
+// ErrPackageClauseChanged returns if rewriting the imports of a file would
+// change its package clause.
+type ErrPackageClauseChanged struct {
+	Path   string
+	Before string
+	After  string
+}
+
+func (err ErrPackageClauseChanged) Error() string {
+	return fmt.Sprintf("Rewriting %q would change the package clause from %q to %q.", err.Path, err.Before, err.After)
+}
+
+// ErrRewriteInvalid returns if rewriting the imports of a file would
+// produce source that does not parse. The file is not changed.
+type ErrRewriteInvalid struct {
+	Path string
+	Err  error
+}
+
+func (err ErrRewriteInvalid) Error() string {
+	return fmt.Sprintf("Rewriting %q would not parse, file left unchanged: %v", err.Path, err.Err)
+}
+
 // ErrOldVersion returns if vendor file is not in the vendor folder.
 type ErrOldVersion struct {
 	Message string
@@ -78,3 +140,61 @@ type ErrTreeParents struct {
 func (err ErrTreeParents) Error() string {
 	return fmt.Sprintf("Cannot add package %q which is already found in sub-tree %q", err.path, err.parents)
 }
+
+// ErrNotVendored returns if a package is expected to be in the vendor file
+// but is not.
+type ErrNotVendored struct {
+	Path string
+}
+
+func (err ErrNotVendored) Error() string {
+	return fmt.Sprintf("Package %q is not in the vendor file.", err.Path)
+}
+
+// ErrArchivePath returns if an archive entry would be unpacked outside of
+// the vendor folder.
+type ErrArchivePath struct {
+	Name string
+}
+
+func (err ErrArchivePath) Error() string {
+	return fmt.Sprintf("Archive entry %q is not in the vendor folder.", err.Name)
+}
+
+// ErrNotInRoot returns if a directory is not inside the project root.
+type ErrNotInRoot struct {
+	Dir  string
+	Root string
+}
+
+func (err ErrNotInRoot) Error() string {
+	return fmt.Sprintf("Directory %q is not in the project root %q.", err.Dir, err.Root)
+}
+
+// ErrVendorFileEncoding returns if the vendor file is not valid UTF-8.
+type ErrVendorFileEncoding struct {
+	Path   string
+	Offset int
+}
+
+func (err ErrVendorFileEncoding) Error() string {
+	return fmt.Sprintf("Vendor file %q is not valid UTF-8 at byte offset %d.", err.Path, err.Offset)
+}
+
+// LoadErrors returns from loading packages when Context.CollectErrors is set
+// and one or more errors were found.
+type LoadErrors []error
+
+func (list LoadErrors) Error() string {
+	if len(list) == 0 {
+		return "(no load error)"
+	}
+	buf := &bytes.Buffer{}
+	buf.WriteString("Failed to load packages:\n")
+	for _, err := range list {
+		buf.WriteString("\t")
+		buf.WriteString(err.Error())
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
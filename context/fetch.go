@@ -77,7 +77,7 @@ func (f *fetcher) op(op *Operation) ([]*Operation, error) {
 	var vcsCmd *VCSCmd
 	repoRootDir := filepath.Join(f.CacheRoot, repoRoot)
 	if err != nil {
-		rr, err := vcs.RepoRootForImportPath(ps.PathOrigin(), false)
+		rr, err := repoRootForImportPath(ps.PathOrigin(), f.Ctx.Insecure)
 		if err != nil {
 			if strings.Contains(err.Error(), "unrecognized import path") {
 				return nextOps, nil
@@ -178,12 +178,17 @@ func (f *fetcher) op(op *Operation) ([]*Operation, error) {
 	// Find the VCS information.
 	system, err := gvvcs.FindVcs(f.CacheRoot, op.Src)
 	if err != nil {
-		return nextOps, fmt.Errorf("failed to find vcs in %q %v", op.Src, err)
+		if _, is := err.(gvvcs.ErrTimeout); !is {
+			return nextOps, fmt.Errorf("failed to find vcs in %q %v", op.Src, err)
+		}
+		f.Ctx.warn(WarnNoVersion, ps.Path, "no version information: %v", err)
+		system = nil
 	}
 	if system != nil {
 		if system.Dirty {
 			return nextOps, ErrDirtyPackage{ps.PathOrigin()}
 		}
+		f.Ctx.recordHistory(vpkg, system.Revision)
 		vpkg.Revision = system.Revision
 		if system.RevisionTime != nil {
 			vpkg.RevisionTime = system.RevisionTime.UTC().Format(time.RFC3339)
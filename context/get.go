@@ -37,7 +37,7 @@ func get(logger io.Writer, gopath string, ps *pkgspec.Pkg, insecure bool) error
 	var vcsCmd *VCSCmd
 	repoRootDir := filepath.Join(gopath, repoRoot)
 	if err != nil {
-		rr, err := vcs.RepoRootForImportPath(ps.PathOrigin(), false)
+		rr, err := repoRootForImportPath(ps.PathOrigin(), insecure)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,28 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"time"
+
+	"github.com/kardianos/govendor/vendorfile"
+)
+
+// recordHistory adds the current revision of vp to its history if it is
+// about to change to revision. Only the last HistoryLimit entries are kept.
+func (ctx *Context) recordHistory(vp *vendorfile.Package, revision string) {
+	if ctx.HistoryLimit <= 0 || len(vp.Revision) == 0 || vp.Revision == revision {
+		return
+	}
+	vp.History = append(vp.History, vendorfile.HistoryEntry{
+		Revision:     vp.Revision,
+		RevisionTime: vp.RevisionTime,
+		Version:      vp.Version,
+		Changed:      time.Now().UTC().Format(time.RFC3339),
+	})
+	if over := len(vp.History) - ctx.HistoryLimit; over > 0 {
+		vp.History = append(vp.History[:0], vp.History[over:]...)
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"testing"
+
+	"github.com/kardianos/govendor/vendorfile"
+)
+
+func TestRecordHistory(t *testing.T) {
+	ctx := &Context{}
+	vp := &vendorfile.Package{Revision: "r1"}
+	ctx.recordHistory(vp, "r2")
+	if len(vp.History) != 0 {
+		t.Fatal("history recorded when not enabled")
+	}
+
+	ctx.HistoryLimit = 2
+	for _, rev := range []string{"r2", "r2", "r3", "r4"} {
+		ctx.recordHistory(vp, rev)
+		vp.Revision = rev
+	}
+	if len(vp.History) != 2 || vp.History[0].Revision != "r2" || vp.History[1].Revision != "r3" {
+		t.Fatalf("got history %v", vp.History)
+	}
+}
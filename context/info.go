@@ -0,0 +1,36 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+// Info describes how the context was resolved. It helps to diagnose why
+// packages are classified or rewritten unexpectedly.
+type Info struct {
+	RootDir        string // Project root folder.
+	RootImportPath string // Import path of the project root.
+	Gopath         string // GOPATH "src" folder the project is in.
+	Goroot         string // Standard library folder.
+	VendorFolder   string // Vendor folder relative to the project root.
+	VendorFile     string // Path to the vendor file.
+	VendorPackages int    // Number of packages in the vendor file.
+}
+
+// Info returns how the context was resolved.
+func (ctx *Context) Info() Info {
+	n := 0
+	for _, vp := range ctx.VendorFile.Package {
+		if !vp.Remove {
+			n++
+		}
+	}
+	return Info{
+		RootDir:        ctx.RootDir,
+		RootImportPath: ctx.RootImportPath,
+		Gopath:         ctx.RootGopath,
+		Goroot:         ctx.Goroot,
+		VendorFolder:   ctx.VendorFolder,
+		VendorFile:     ctx.VendorFilePath,
+		VendorPackages: n,
+	}
+}
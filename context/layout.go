@@ -0,0 +1,61 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"strings"
+
+	"github.com/kardianos/govendor/pkgspec"
+)
+
+type layoutEntry struct {
+	path   string
+	origin string
+	tree   bool
+}
+
+// CheckVendorLayout checks if the given packages can be vendored together
+// with the packages already in the vendor file without any change being made.
+// It reports packages that would be placed inside a tree package, paths
+// requested from more than one origin, and paths that only differ by case
+// and would share a folder on some file systems.
+func (ctx *Context) CheckVendorLayout(pkgs []*pkgspec.Pkg) []Warning {
+	var all []layoutEntry
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove {
+			continue
+		}
+		all = append(all, layoutEntry{path: vp.Path, origin: vp.PathOrigin(), tree: vp.Tree})
+	}
+	existing := len(all)
+	for _, ps := range pkgs {
+		all = append(all, layoutEntry{path: ps.Path, origin: ps.PathOrigin(), tree: ps.IncludeTree})
+	}
+
+	var warning []Warning
+	add := func(code WarningCode, path, msg string) {
+		warning = append(warning, Warning{Code: code, Path: path, Message: msg})
+	}
+	// Compare each requested package to every entry before it, so each
+	// pair is only reported once.
+	for i := existing; i < len(all); i++ {
+		a := all[i]
+		for _, b := range all[:i] {
+			switch {
+			case a.path == b.path:
+				if a.origin != b.origin {
+					add(WarnLayoutDuplicate, a.path, "requested from both "+b.origin+" and "+a.origin)
+				}
+			case strings.EqualFold(a.path, b.path):
+				add(WarnLayoutCase, a.path, "only differs by case from "+b.path)
+			case b.tree && strings.HasPrefix(a.path, b.path+"/"):
+				add(WarnLayoutNested, a.path, "would be inside tree package "+b.path)
+			case a.tree && strings.HasPrefix(b.path, a.path+"/"):
+				add(WarnLayoutNested, a.path, "tree package would contain "+b.path)
+			}
+		}
+	}
+	return warning
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/kardianos/govendor/internal/pathos"
@@ -180,10 +181,44 @@ func licenseCopy(root, startIn, vendorRoot, pkgPath string) error {
 		if err = os.MkdirAll(destDir, 0777); err != nil {
 			return errors.Wrapf(err, "Failed to create the directory %q", destDir)
 		}
-		return errors.Wrapf(copyFile(destPath, srcPath, nil), "copyFile dest=%q src=%q", destPath, srcPath)
+		return errors.Wrapf(copyFile(destPath, srcPath, nil, nil, nil), "copyFile dest=%q src=%q", destPath, srcPath)
 	})
 }
 
+// PackagesMissingLicense returns the import paths of vendored packages with
+// no license file in the package folder or a parent folder in the vendor
+// folder. Licenses are copied into the vendor folder when a package is
+// added, so these dependencies need a license reviewed by hand.
+func (ctx *Context) PackagesMissingLicense() ([]string, error) {
+	vendorRoot := filepath.Join(ctx.RootDir, ctx.VendorFolder)
+	var missing []string
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove {
+			continue
+		}
+		dir := filepath.Join(vendorRoot, filepath.FromSlash(vp.Path))
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		hasLicense := false
+		err := licenseWalk(vendorRoot, dir, func(folder, name string) error {
+			// A license in the vendor folder itself is not of the package.
+			if !pathos.FileStringEquals(folder, vendorRoot) {
+				hasLicense = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !hasLicense {
+			missing = append(missing, vp.Path)
+		}
+	}
+	sort.Strings(missing)
+	return missing, nil
+}
+
 func getLastVendorRoot(s string) string {
 	w := strings.Replace(s, "\\", "/", -1)
 	ix := strings.LastIndex(w, "/vendor/")
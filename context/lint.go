@@ -0,0 +1,277 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kardianos/govendor/internal/pathos"
+	filepath "github.com/kardianos/govendor/internal/vfilepath"
+)
+
+// StrayVendorImports finds imports in project packages that spell out a path
+// in the vendor folder, such as "project/vendor/github.com/a/b", where the
+// vendor file has no package for that path. These are usually copy and paste
+// mistakes; rewritten imports of vendored packages are not reported.
+func (ctx *Context) StrayVendorImports() ([]Warning, error) {
+	list, err := ctx.Status()
+	if err != nil {
+		return nil, err
+	}
+	prefix := ctx.InternalPrefix() + "/"
+	var warning []Warning
+	for _, item := range list {
+		if item.Status.Location != LocationLocal {
+			continue
+		}
+		pkg := ctx.Package[item.Local]
+		if pkg == nil {
+			continue
+		}
+		for _, f := range pkg.Files {
+			fileset := token.NewFileSet()
+			af, _ := parser.ParseFile(fileset, f.Path, nil, parser.ImportsOnly)
+			if af == nil {
+				continue
+			}
+			for _, impNode := range af.Imports {
+				imp, err := strconv.Unquote(impNode.Path.Value)
+				if err != nil || !strings.HasPrefix(imp, prefix) {
+					continue
+				}
+				if ctx.vendorFileCovers(strings.TrimPrefix(imp, prefix)) {
+					continue
+				}
+				warning = append(warning, Warning{
+					Code:    WarnStrayVendorImport,
+					Path:    f.Path,
+					Message: fmt.Sprintf("line %d imports %q which is not a vendored package", fileset.Position(impNode.Pos()).Line, imp),
+				})
+			}
+		}
+	}
+	return warning, nil
+}
+
+// UntrackedVendor finds packages in the vendor folder that are not in the
+// vendor file and are not inside a vendored tree package. These were most
+// likely copied by hand; they are not updated or checked until added to
+// the vendor file. Packages in nested vendor folders are not reported.
+func (ctx *Context) UntrackedVendor() ([]Warning, error) {
+	list, err := ctx.Status()
+	if err != nil {
+		return nil, err
+	}
+	prefix := ctx.InternalPrefix() + "/"
+	var warning []Warning
+	for _, item := range list {
+		if item.Status.Location != LocationVendor || item.Local != prefix+item.Pkg.Path {
+			continue
+		}
+		if ctx.vendorFileCovers(item.Pkg.Path) {
+			continue
+		}
+		warning = append(warning, Warning{
+			Code:    WarnUntrackedVendor,
+			Path:    item.Local,
+			Message: "in the vendor folder but not in the vendor file",
+		})
+	}
+	sort.Sort(warningSort(warning))
+	return warning, nil
+}
+
+// MixedImports finds dependencies that are imported by both the original
+// path and the vendored path, such as "github.com/a/b" and
+// "project/vendor/github.com/a/b". These are two copies of the same code at
+// run time and usually mean an import rewrite was not finished. Each file
+// that imports either form is reported.
+func (ctx *Context) MixedImports() ([]Warning, error) {
+	if _, err := ctx.Status(); err != nil {
+		return nil, err
+	}
+	prefix := ctx.InternalPrefix() + "/"
+	// map[original path]map[import path][]file path
+	forms := make(map[string]map[string][]string)
+	for _, pkg := range ctx.Package {
+		if !pathos.FileHasPrefix(pkg.Dir, ctx.RootDir) {
+			continue
+		}
+		for _, f := range pkg.Files {
+			for _, imp := range f.Imports {
+				if len(imp) == 0 {
+					continue
+				}
+				original := strings.TrimPrefix(imp, prefix)
+				m := forms[original]
+				if m == nil {
+					m = make(map[string][]string, 2)
+					forms[original] = m
+				}
+				m[imp] = append(m[imp], f.Path)
+			}
+		}
+	}
+	var warning []Warning
+	for original, m := range forms {
+		vendored := prefix + original
+		if len(m[original]) == 0 || len(m[vendored]) == 0 {
+			continue
+		}
+		for imp, other := range map[string]string{original: vendored, vendored: original} {
+			for _, fp := range m[imp] {
+				warning = append(warning, Warning{
+					Code:    WarnMixedImport,
+					Path:    fp,
+					Message: fmt.Sprintf("imports %q while other files import %q", imp, other),
+				})
+			}
+		}
+	}
+	sort.Sort(warningSort(warning))
+	return warning, nil
+}
+
+// VendorImportsProject finds vendored packages that import a package of the
+// project outside of the vendor folder. A vendored package should be self
+// contained; such an import couples the dependency to the project and may
+// form an import cycle. This happens when the project shares a path prefix
+// with a dependency or after an import rewrite went wrong.
+func (ctx *Context) VendorImportsProject() ([]Warning, error) {
+	if _, err := ctx.Status(); err != nil {
+		return nil, err
+	}
+	prefix := ctx.InternalPrefix() + "/"
+	var warning []Warning
+	for _, pkg := range ctx.Package {
+		if !strings.HasPrefix(pkg.Local, prefix) {
+			continue
+		}
+		for _, f := range pkg.Files {
+			for _, imp := range f.Imports {
+				if strings.HasPrefix(imp, prefix) || !filepath.HasPrefixDir(imp, ctx.RootImportPath) {
+					continue
+				}
+				warning = append(warning, Warning{
+					Code:    WarnVendorImportsProject,
+					Path:    f.Path,
+					Message: fmt.Sprintf("vendored package imports %q from the project", imp),
+				})
+			}
+		}
+	}
+	sort.Sort(warningSort(warning))
+	return warning, nil
+}
+
+// GopathShadow finds vendored packages that are also imported from a copy
+// in GOPATH. Packages outside of the project, such as external packages
+// the project imports, do not see the vendor folder and build the GOPATH
+// copy, so two versions of the same code end up in the program.
+func (ctx *Context) GopathShadow() ([]Warning, error) {
+	list, err := ctx.Status()
+	if err != nil {
+		return nil, err
+	}
+	var warning []Warning
+	for _, item := range list {
+		if item.Status.Location != LocationExternal || item.Pkg.Path != item.Local {
+			continue
+		}
+		if ctx.VendorFilePackagePath(item.Pkg.Path) == nil {
+			continue
+		}
+		by := make([]string, len(item.ImportedBy))
+		for i, ref := range item.ImportedBy {
+			by[i] = ref.Local
+		}
+		warning = append(warning, Warning{
+			Code:    WarnGopathShadow,
+			Path:    item.Pkg.Path,
+			Message: fmt.Sprintf("vendored but also built from %q for %s", item.Pkg.FilePath, strings.Join(by, ", ")),
+		})
+	}
+	sort.Sort(warningSort(warning))
+	return warning, nil
+}
+
+// InternalImports finds project and vendor packages that import an
+// "internal" package they may not import. A package under an "internal"
+// directory may only be imported by packages rooted at the parent of that
+// directory. Vendored packages are compared by their canonical paths, so
+// an internal package of a dependency stays private to that dependency
+// after it is copied into the vendor folder.
+func (ctx *Context) InternalImports() ([]Warning, error) {
+	if _, err := ctx.Status(); err != nil {
+		return nil, err
+	}
+	var warning []Warning
+	for _, pkg := range ctx.Package {
+		if !pathos.FileHasPrefix(pkg.Dir, ctx.RootDir) {
+			continue
+		}
+		found := make(map[string]bool)
+		for _, f := range pkg.Files {
+			for _, imp := range f.Imports {
+				if len(imp) == 0 || found[imp] {
+					continue
+				}
+				found[imp] = true
+				canonical := imp
+				if target := ctx.Package[imp]; target != nil {
+					if target.Status.Location == LocationStandard {
+						continue
+					}
+					canonical = target.Path
+				}
+				parent, is := internalParent(canonical)
+				if !is || pkg.Path == parent || strings.HasPrefix(pkg.Path, parent+"/") {
+					continue
+				}
+				warning = append(warning, Warning{
+					Code:    WarnInternalImport,
+					Path:    pkg.Local,
+					Message: fmt.Sprintf("imports %q which is internal to %q", imp, parent),
+				})
+			}
+		}
+	}
+	sort.Sort(warningSort(warning))
+	return warning, nil
+}
+
+// internalParent returns the path that may import the internal package
+// importPath. Returns false if importPath is not an internal package.
+func internalParent(importPath string) (string, bool) {
+	switch {
+	case strings.HasPrefix(importPath, "internal/") || importPath == "internal":
+		return "", false // Standard library only.
+	case strings.HasSuffix(importPath, "/internal"):
+		return strings.TrimSuffix(importPath, "/internal"), true
+	}
+	if i := strings.LastIndex(importPath, "/internal/"); i >= 0 {
+		return importPath[:i], true
+	}
+	return "", false
+}
+
+// vendorFileCovers reports if the import path is a package in the vendor
+// file or is inside a vendored tree package.
+func (ctx *Context) vendorFileCovers(importPath string) bool {
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove {
+			continue
+		}
+		if vp.Path == importPath || (vp.Tree && strings.HasPrefix(importPath, vp.Path+"/")) {
+			return true
+		}
+	}
+	return false
+}
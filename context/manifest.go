@@ -0,0 +1,102 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/kardianos/govendor/internal/pathos"
+	"github.com/kardianos/govendor/vendorfile"
+)
+
+// UpdateFileManifest records the checksum of each file of a vendored package
+// in the vendor file. The file manifest is opt-in: once a package has one it
+// is kept up to date when the package is copied again. The vendor file must
+// be written afterwards to persist the manifest.
+func (ctx *Context) UpdateFileManifest(importPath string) error {
+	vp := ctx.VendorFilePackagePath(importPath)
+	if vp == nil {
+		return ErrNotVendored{Path: importPath}
+	}
+	files, err := ctx.fileManifest(vp)
+	if err != nil {
+		return err
+	}
+	vp.Files = files
+	return nil
+}
+
+// VerifyFileManifest compares the files of a vendored package to its file
+// manifest. It returns the relative paths of files that were modified, added
+// or removed since the manifest was recorded.
+func (ctx *Context) VerifyFileManifest(importPath string) (drift []string, err error) {
+	vp := ctx.VendorFilePackagePath(importPath)
+	if vp == nil {
+		return nil, ErrNotVendored{Path: importPath}
+	}
+	files, err := ctx.fileManifest(vp)
+	if err != nil {
+		return nil, err
+	}
+	want := make(map[string]string, len(vp.Files))
+	for _, fc := range vp.Files {
+		want[fc.Path] = fc.ChecksumSHA1
+	}
+	for _, fc := range files {
+		checksum, found := want[fc.Path]
+		delete(want, fc.Path)
+		if !found || checksum != fc.ChecksumSHA1 {
+			drift = append(drift, fc.Path)
+		}
+	}
+	for p := range want {
+		drift = append(drift, p)
+	}
+	sort.Strings(drift)
+	return drift, nil
+}
+
+// fileManifest hashes the files of a vendored package, using the same files
+// as the package checksum.
+func (ctx *Context) fileManifest(vp *vendorfile.Package) ([]vendorfile.FileChecksum, error) {
+	dir := filepath.Join(ctx.RootDir, ctx.VendorFolder, pathos.SlashToFilepath(vp.Path))
+	var files []vendorfile.FileChecksum
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if p != dir && !vp.Tree {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		h := sha1.New()
+		_, err = io.Copy(h, f)
+		if err != nil {
+			return err
+		}
+		files = append(files, vendorfile.FileChecksum{
+			Path:         filepath.ToSlash(rel),
+			ChecksumSHA1: base64.StdEncoding.EncodeToString(h.Sum(nil)),
+		})
+		return nil
+	})
+	return files, err
+}
@@ -12,6 +12,7 @@ import (
 	"crypto/sha1"
 	"encoding/base64"
 	"fmt"
+	"io/ioutil"
 	"math"
 	"path"
 	"path/filepath"
@@ -155,6 +156,9 @@ statusLoop:
 			if _, is := err.(ErrTreeParents); is {
 				continue
 			}
+			if _, is := err.(ErrLocalPackage); is {
+				continue
+			}
 			return err
 		}
 	}
@@ -169,13 +173,8 @@ func (ctx *Context) ModifyImport(imp *pkgspec.Pkg, mod Modify, mops ...ModifyOpt
 	}
 	// Grap the origin of the pkg spec from the vendor file as needed.
 	if len(imp.Origin) == 0 {
-		for _, vpkg := range ctx.VendorFile.Package {
-			if vpkg.Remove {
-				continue
-			}
-			if vpkg.Path == imp.Path {
-				imp.Origin = vpkg.Origin
-			}
+		if vpkg, found := ctx.FindByVendor(imp.Path); found {
+			imp.Origin = vpkg.Origin
 		}
 	}
 	if !imp.MatchTree {
@@ -239,10 +238,11 @@ func (ctx *Context) modify(ps *pkgspec.Pkg, mod Modify, mops []ModifyOption) err
 	}
 	tree := ps.IncludeTree
 
+	var originDir string
 	switch mod {
 	// Determine if we can find the source path from an add or update.
 	case Add, Update, AddUpdate:
-		_, _, err = ctx.findImportDir("", ps.PathOrigin())
+		originDir, _, err = ctx.findImportDir("", ps.PathOrigin())
 		if err != nil {
 			return err
 		}
@@ -254,7 +254,7 @@ func (ctx *Context) modify(ps *pkgspec.Pkg, mod Modify, mops []ModifyOption) err
 	var pkg *Package
 	var foundPkg bool
 	if !foundPkg {
-		localPath := path.Join(ctx.RootImportPath, ctx.VendorFolder, ps.Path)
+		localPath := path.Join(ctx.InternalPrefix(), ps.Path)
 		pkg, foundPkg = ctx.Package[localPath]
 		foundPkg = foundPkg && pkg.Status.Presence != PresenceMissing
 	}
@@ -278,9 +278,17 @@ func (ctx *Context) modify(ps *pkgspec.Pkg, mod Modify, mops []ModifyOption) err
 		pkg.Path = ps.Path
 	}
 
+	if mod != Remove && pkg.Status.Location == LocationLocal {
+		return ErrLocalPackage{ImportPath: pkg.Path, Root: ctx.RootImportPath}
+	}
+
 	pkg.HasOrigin = ps.HasOrigin
 	if ps.HasOrigin {
 		pkg.Origin = ps.Origin
+		// A vendored package may have been copied from another origin.
+		if len(ps.Origin) != 0 && len(originDir) != 0 {
+			pkg.OriginDir = originDir
+		}
 	}
 
 	// Do not support setting "tree" on Remove.
@@ -308,7 +316,7 @@ func (ctx *Context) modify(ps *pkgspec.Pkg, mod Modify, mops []ModifyOption) err
 		return err
 	}
 	if mod == Add && localExists {
-		return ErrPackageExists{path.Join(ctx.RootImportPath, ctx.VendorFolder, ps.Path)}
+		return ErrPackageExists{path.Join(ctx.InternalPrefix(), ps.Path)}
 	}
 	dprintf("stage 2: begin!\n")
 	switch mod {
@@ -351,9 +359,10 @@ func (ctx *Context) getIgnoreFiles(src string) (ignoreFile, imports []string, er
 			return nil, nil, err
 		}
 
-		if tags.IgnoreItem(ctx.ignoreTag...) {
+		if tags.IgnoreCopy(ctx.ignoreTag...) {
 			ignoreFile = append(ignoreFile, fi.Name())
-		} else {
+		}
+		if !tags.IgnoreItem(ctx.ignoreTag...) {
 			// Only add imports for non-ignored files.
 			for _, imp := range fileImports {
 				importMap[imp] = struct{}{}
@@ -403,6 +412,17 @@ func (ctx *Context) modifyAdd(pkg *Package, uncommitted bool) error {
 	if pkg.IncludeTree {
 		vp.Tree = pkg.IncludeTree
 	}
+	if len(ctx.ExcludeDir) > 0 {
+		for _, glob := range ctx.ExcludeDir {
+			if _, err := path.Match(glob, ""); err != nil {
+				return errors.Wrapf(err, "invalid exclude glob %q", glob)
+			}
+		}
+		vp.Exclude = append([]string(nil), ctx.ExcludeDir...)
+	}
+	if ctx.MaxFileSize > 0 {
+		vp.MaxFileSize = ctx.MaxFileSize
+	}
 
 	if pkg.HasOrigin {
 		vp.Origin = pkg.Origin
@@ -414,7 +434,12 @@ func (ctx *Context) modifyAdd(pkg *Package, uncommitted bool) error {
 	// Find the VCS information.
 	system, err := vcs.FindVcs(pkg.Gopath, src)
 	if err != nil {
-		return err
+		if _, is := err.(vcs.ErrTimeout); !is {
+			return err
+		}
+		// Continue without version information rather than block.
+		ctx.warn(WarnNoVersion, pkg.Path, "no version information: %v", err)
+		system = nil
 	}
 	dirtyAndUncommitted := false
 	if system != nil {
@@ -427,6 +452,7 @@ func (ctx *Context) modifyAdd(pkg *Package, uncommitted bool) error {
 				vp.ChecksumSHA1 = "uncommitted/version="
 			}
 		} else {
+			ctx.recordHistory(vp, system.Revision)
 			vp.Revision = system.Revision
 			if system.RevisionTime != nil {
 				vp.RevisionTime = system.RevisionTime.UTC().Format(time.RFC3339)
@@ -451,7 +477,7 @@ func (ctx *Context) modifyAdd(pkg *Package, uncommitted bool) error {
 	ctx.makeSet(pkg, mvSet)
 
 	for r := range mvSet {
-		to := path.Join(ctx.RootImportPath, ctx.VendorFolder, r.Path)
+		to := path.Join(ctx.InternalPrefix(), r.Path)
 		dprintf("RULE: %s -> %s\n", r.Local, to)
 		ctx.RewriteRule[r.Path] = to
 		ctx.RewriteRule[r.Local] = to
@@ -516,7 +542,7 @@ func (ctx *Context) modifyFetch(pkg *Package, uncommitted, hasVersion bool, vers
 	if pkg.IncludeTree {
 		vp.Tree = pkg.IncludeTree
 	}
-	pkg.Origin = strings.TrimPrefix(pkg.Origin, ctx.RootImportPath+"/"+ctx.VendorFolder+"/")
+	pkg.Origin = strings.TrimPrefix(pkg.Origin, ctx.InternalPrefix()+"/")
 	vp.Origin = pkg.Origin
 	origin := vp.Origin
 	if len(vp.Origin) == 0 {
@@ -555,7 +581,7 @@ func (ctx *Context) Check() []*Conflict {
 		if len(lop) == 1 {
 			continue
 		}
-		destDir := path.Join(ctx.RootImportPath, ctx.VendorFolder, canonical)
+		destDir := path.Join(ctx.InternalPrefix(), canonical)
 		ret = append(ret, &Conflict{
 			Canonical: canonical,
 			Local:     destDir,
@@ -717,7 +743,10 @@ func (ctx *Context) Alter() error {
 		}
 		ctx.Operation = append(ctx.Operation, nextOps...)
 	}
-	// Move and possibly rewrite packages.
+	// Move and possibly rewrite packages. Dependencies are copied first and
+	// imports are only rewritten once every package is in place.
+	ctx.Operation = dependencyOrder(ctx.Operation)
+	var removed []*Package
 	for _, op := range ctx.Operation {
 		if op.State != OpReady {
 			continue
@@ -733,26 +762,84 @@ func (ctx *Context) Alter() error {
 		default:
 			panic("unknown operation type")
 		case OpRemove:
+			ctx.logf(1, "removing %s\n", pkg.Path)
 			ctx.dirty = true
 			err = RemovePackage(op.Src, filepath.Join(ctx.RootDir, ctx.VendorFolder), pkg.IncludeTree)
 			op.State = OpDone
+			removed = append(removed, pkg)
 		case OpCopy:
 			err = ctx.copyOperation(op, nil)
 			if os.IsNotExist(errors.Cause(err)) {
 				// Ignore packages that don't exist, like appengine.
 				err = nil
 			}
+			if err == nil {
+				var warning []Warning
+				warning, err = copiedParentHeaders(op.Dest, pkg.IncludeTree)
+				ctx.Warning = append(ctx.Warning, warning...)
+			}
 		}
 		if err != nil {
 			return errors.Wrapf(err, "Failed to %v package %q -> %q", op.Type, op.Src, op.Dest)
 		}
 	}
+	ctx.checkRemoved(removed)
 	if ctx.rewriteImports {
 		return ctx.rewrite()
 	}
 	return nil
 }
 
+// dependencyOrder orders copy operations so a package is copied after the
+// packages it imports that are also being copied. Otherwise the order is
+// kept. Import cycles are broken at the first package of the cycle.
+func dependencyOrder(ops []*Operation) []*Operation {
+	byPath := make(map[string]*Operation, len(ops))
+	for _, op := range ops {
+		if op.Type == OpCopy {
+			byPath[op.Pkg.Path] = op
+			byPath[op.Pkg.Local] = op
+		}
+	}
+	out := make([]*Operation, 0, len(ops))
+	visited := make(map[*Operation]bool, len(ops))
+	var visit func(op *Operation)
+	visit = func(op *Operation) {
+		if visited[op] {
+			return
+		}
+		visited[op] = true
+		if op.Type == OpCopy {
+			for _, f := range op.Pkg.Files {
+				for _, imp := range f.Imports {
+					if dep := byPath[imp]; dep != nil {
+						visit(dep)
+					}
+				}
+			}
+		}
+		out = append(out, op)
+	}
+	for _, op := range ops {
+		visit(op)
+	}
+	return out
+}
+
+// checkRemoved warns about removed packages that are still imported but
+// can no longer be found outside of the vendor folder. Imports of these
+// packages would be missing until they are fetched into GOPATH.
+func (ctx *Context) checkRemoved(removed []*Package) {
+	for _, pkg := range removed {
+		if len(pkg.referenced) == 0 {
+			continue
+		}
+		if _, _, err := ctx.findImportDir("", pkg.Path); err != nil {
+			ctx.warn(WarnRemovedMissing, pkg.Path, "still imported but not found in GOPATH after remove, run \"go get %s\"", pkg.Path)
+		}
+	}
+}
+
 func (ctx *Context) copyOperation(op *Operation, beforeCopy func(deps []string) error) error {
 	var err error
 	pkg := op.Pkg
@@ -762,17 +849,47 @@ func (ctx *Context) copyOperation(op *Operation, beforeCopy func(deps []string)
 
 	root, _ := pathos.TrimCommonSuffix(op.Src, pkg.Path)
 
+	if ctx.rewriteImports {
+		// Rewrite imports of the copied files while copying, rewrite
+		// then only needs to update the files of the project.
+		ctx.copyRewriteRule = ctx.RewriteRule
+		defer func() { ctx.copyRewriteRule = nil }()
+	}
 	err = ctx.CopyPackage(op.Dest, op.Src, root, pkg.Path, op.IgnoreFile, pkg.IncludeTree, h, beforeCopy)
 	if err == nil && !op.Uncommitted {
 		checksum = h.Sum(nil)
 		vpkg := ctx.VendorFilePackagePath(pkg.Path)
 		if vpkg != nil {
 			vpkg.ChecksumSHA1 = base64.StdEncoding.EncodeToString(checksum)
+			if len(vpkg.Files) > 0 {
+				vpkg.Files, err = ctx.fileManifest(vpkg)
+			}
 		}
 	}
 	op.State = OpDone
 	if err != nil {
 		return errors.Wrapf(err, "copy failed. dest: %q, src: %q, pkgPath %q", op.Dest, op.Src, root)
 	}
+	if ctx.verbose(1) {
+		ctx.logf(1, "copying %s (%d files)\n", pkg.Path, countFiles(op.Dest, pkg.IncludeTree))
+	}
 	return nil
 }
+
+// countFiles counts the files in dir, including sub-directories if tree is set.
+func countFiles(dir string, tree bool) int {
+	fl, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	n := 0
+	for _, fi := range fl {
+		switch {
+		case !fi.IsDir():
+			n++
+		case tree:
+			n += countFiles(filepath.Join(dir, fi.Name()), tree)
+		}
+	}
+	return n
+}
@@ -0,0 +1,100 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kardianos/govendor/internal/pathos"
+	os "github.com/kardianos/govendor/internal/vos"
+)
+
+// OrphanDirs lists directories under the vendor folder that contain no Go
+// files and are not part of any package in the vendor file. These are often
+// left over after files are deleted by hand. Only the top most orphaned
+// directory is listed, as a slash separated path relative to the vendor folder.
+func (ctx *Context) OrphanDirs() ([]string, error) {
+	root := filepath.Join(ctx.RootDir, ctx.VendorFolder)
+	var orphan []string
+	_, err := ctx.walkOrphan(root, "", &orphan)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return orphan, err
+}
+
+// RemoveOrphanDirs removes the directories listed by OrphanDirs and
+// returns them.
+func (ctx *Context) RemoveOrphanDirs() ([]string, error) {
+	orphan, err := ctx.OrphanDirs()
+	if err != nil {
+		return nil, err
+	}
+	root := filepath.Join(ctx.RootDir, ctx.VendorFolder)
+	for _, dir := range orphan {
+		err = os.RemoveAll(filepath.Join(root, pathos.SlashToFilepath(dir)))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return orphan, nil
+}
+
+// walkOrphan reports if the directory should be kept. Child directories that
+// should not be kept are added to orphan.
+func (ctx *Context) walkOrphan(dir, rel string, orphan *[]string) (keep bool, err error) {
+	fl, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false, err
+	}
+	keep = len(rel) == 0 || ctx.inVendorPackage(rel)
+	var children []string
+	for _, fi := range fl {
+		name := fi.Name()
+		if !fi.IsDir() {
+			if strings.HasSuffix(name, ".go") {
+				keep = true
+			}
+			continue
+		}
+		childRel := path.Join(rel, name)
+		childKeep, err := ctx.walkOrphan(filepath.Join(dir, name), childRel, orphan)
+		if err != nil {
+			return false, err
+		}
+		if childKeep {
+			keep = true
+			continue
+		}
+		children = append(children, childRel)
+	}
+	if keep {
+		*orphan = append(*orphan, children...)
+	}
+	return keep, nil
+}
+
+// inVendorPackage reports if the vendor relative path is a package in the
+// vendor file, is inside a tree package, or is a testdata folder of a package.
+func (ctx *Context) inVendorPackage(rel string) bool {
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove {
+			continue
+		}
+		if vp.Path == rel {
+			return true
+		}
+		if !strings.HasPrefix(rel, vp.Path+"/") {
+			continue
+		}
+		if vp.Tree || strings.Contains("/"+rel+"/", "/testdata/") {
+			return true
+		}
+	}
+	return false
+}
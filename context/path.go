@@ -7,14 +7,26 @@ package context
 import (
 	"io"
 	"path/filepath"
+	"strings"
 
 	"github.com/kardianos/govendor/internal/pathos"
 	os "github.com/kardianos/govendor/internal/vos"
 )
 
+// isMagicImport returns true if the import path or a parent of it is
+// listed in MagicImport.
+func (ctx *Context) isMagicImport(importPath string) bool {
+	for _, magic := range ctx.MagicImport {
+		if importPath == magic || strings.HasPrefix(importPath, magic+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 // Import path is in GOROOT or is a special package.
 func (ctx *Context) isStdLib(importPath string) (yes bool, err error) {
-	if importPath == "builtin" || importPath == "unsafe" || importPath == "C" {
+	if ctx.isMagicImport(importPath) {
 		yes = true
 		return
 	}
@@ -35,9 +47,16 @@ func (ctx *Context) isStdLib(importPath string) (yes bool, err error) {
 // findImportDir finds the absolute directory. If rel is empty vendor folders
 // are not looked in.
 func (ctx *Context) findImportDir(relative, importPath string) (dir, gopath string, err error) {
-	if importPath == "builtin" || importPath == "unsafe" || importPath == "C" {
+	if ctx.isMagicImport(importPath) {
 		return filepath.Join(ctx.Goroot, importPath), ctx.Goroot, nil
 	}
+	// Packages under an overridden root import path are found in the project.
+	if ctx.gopathImportPath != ctx.RootImportPath && strings.HasPrefix(importPath+"/", ctx.RootImportPath+"/") {
+		dir := filepath.Join(ctx.RootDir, pathos.SlashToFilepath(strings.TrimPrefix(importPath, ctx.RootImportPath)))
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			return dir, ctx.RootGopath, nil
+		}
+	}
 	if len(relative) != 0 {
 		rel := relative
 		for {
@@ -89,6 +108,55 @@ func (ctx *Context) findImportDir(relative, importPath string) (dir, gopath stri
 	return "", "", ErrNotInGOPATH{importPath}
 }
 
+// GopathDirs returns the directory of the import path in each GOPATH it is
+// found in, in GOPATH order. The standard library is not looked in. More
+// than one directory means the package copied by add depends on the order
+// of GOPATH, as the first one is used.
+func (ctx *Context) GopathDirs(importPath string) []string {
+	var dirs []string
+	seen := make(map[string]bool, 2)
+	for _, gopath := range ctx.GopathList {
+		if pathos.FileStringEquals(gopath, ctx.Goroot) {
+			continue
+		}
+		dir := filepath.Join(gopath, pathos.SlashToFilepath(importPath))
+		fi, err := os.Stat(dir)
+		if err != nil || !fi.IsDir() {
+			continue
+		}
+		// The GOPATH list has each GOPATH with and without symlinks evaluated.
+		real, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			real = dir
+		}
+		if seen[real] {
+			continue
+		}
+		seen[real] = true
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// AmbiguousImports returns the external packages the project uses that are
+// found in more than one GOPATH, with the directory in each GOPATH.
+func (ctx *Context) AmbiguousImports() (map[string][]string, error) {
+	list, err := ctx.Status()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]string)
+	for _, item := range list {
+		if item.Status.Location != LocationExternal {
+			continue
+		}
+		if dirs := ctx.GopathDirs(item.Pkg.Path); len(dirs) > 1 {
+			out[item.Pkg.Path] = dirs
+		}
+	}
+	return out, nil
+}
+
 // findImportPath takes a absolute directory and returns the import path and go path.
 func (ctx *Context) findImportPath(dir string) (importPath, gopath string, err error) {
 	dirResolved, err := filepath.EvalSymlinks(dir)
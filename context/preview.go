@@ -0,0 +1,68 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"path"
+	"sort"
+
+	"github.com/kardianos/govendor/internal/pathos"
+)
+
+// AddPreview describes what adding a package to the vendor folder would change.
+type AddPreview struct {
+	Local string   // Import path of the package once vendored.
+	From  string   // Import path that is rewritten to Local.
+	Files []string // Project files that import From, sorted.
+
+	// Rewrite is true if the context rewrites imports, so Files would change.
+	Rewrite bool
+
+	// Diff is the unified diff of the import rewrite of each file in Files,
+	// keyed by file path. Only set if Rewrite is true.
+	Diff map[string]string
+}
+
+// AddPreview reports the files in the project that import importPath and
+// the rewrite rule that adding it would apply, with the diff of each file
+// if imports are rewritten. Nothing is changed.
+func (ctx *Context) AddPreview(importPath string) (AddPreview, error) {
+	preview := AddPreview{
+		Local:   path.Join(ctx.InternalPrefix(), importPath),
+		From:    importPath,
+		Rewrite: ctx.rewriteImports,
+	}
+	if _, err := ctx.Status(); err != nil {
+		return preview, err
+	}
+	for _, pkg := range ctx.Package {
+		if !pathos.FileHasPrefix(pkg.Dir, ctx.RootDir) {
+			continue
+		}
+	fileLoop:
+		for _, f := range pkg.Files {
+			for _, imp := range f.Imports {
+				if imp == importPath {
+					preview.Files = append(preview.Files, f.Path)
+					continue fileLoop
+				}
+			}
+		}
+	}
+	sort.Strings(preview.Files)
+	if !preview.Rewrite {
+		return preview, nil
+	}
+	rules := map[string]string{preview.From: preview.Local}
+	preview.Diff = make(map[string]string, len(preview.Files))
+	for _, fp := range preview.Files {
+		diff, err := rewriteDiff(fp, rules)
+		if err != nil {
+			return preview, err
+		}
+		preview.Diff[fp] = diff
+	}
+	return preview, nil
+}
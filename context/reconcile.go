@@ -0,0 +1,53 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"sort"
+
+	"github.com/kardianos/govendor/pkgspec"
+)
+
+// Reconcile makes the vendor folder match the imports of the project.
+// Every external package the project uses is added and every unused
+// vendored package is removed, then the vendor file is written once.
+// It returns the import paths of the added and removed packages.
+func (ctx *Context) Reconcile() (added, removed []string, err error) {
+	list, err := ctx.Status()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, item := range list {
+		switch {
+		case item.Status.Location == LocationExternal && item.Status.Presence != PresenceExcluded:
+			added = append(added, item.Pkg.Path)
+		case item.Status.Location == LocationVendor && item.Status.Presence == PresenceUnused:
+			removed = append(removed, item.Pkg.Path)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	if len(added) == 0 && len(removed) == 0 {
+		return nil, nil, nil
+	}
+	for _, p := range added {
+		err = ctx.ModifyImport(&pkgspec.Pkg{Path: p}, Add)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, p := range removed {
+		err = ctx.ModifyImport(&pkgspec.Pkg{Path: p}, Remove)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	err = ctx.Alter()
+	vferr := ctx.WriteVendorFile()
+	if err != nil {
+		return nil, nil, err
+	}
+	return added, removed, vferr
+}
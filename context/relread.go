@@ -0,0 +1,106 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// fileReadFuncs are functions that take a file path as the first argument.
+var fileReadFuncs = map[string]map[string]bool{
+	"os": {
+		"Open":     true,
+		"OpenFile": true,
+		"ReadFile": true,
+		"ReadDir":  true,
+		"Stat":     true,
+		"Lstat":    true,
+	},
+	"ioutil": {
+		"ReadFile": true,
+		"ReadDir":  true,
+	},
+}
+
+// RelativeFileReads finds vendor package files that open a file using a
+// relative path string literal, such as reading test data. Each call found
+// is returned as a warning. If the file does not exist relative to the
+// vendored package the message says so. Calls are matched on the package
+// name at the call site, so renamed imports are not found.
+func (ctx *Context) RelativeFileReads() ([]Warning, error) {
+	list, err := ctx.Status()
+	if err != nil {
+		return nil, err
+	}
+	var warning []Warning
+	for _, item := range list {
+		if item.Status.Location != LocationVendor {
+			continue
+		}
+		pkg := ctx.Package[item.Local]
+		if pkg == nil {
+			continue
+		}
+		for _, f := range pkg.Files {
+			w, err := relativeFileReads(f.Path)
+			if err != nil {
+				return nil, err
+			}
+			warning = append(warning, w...)
+		}
+	}
+	return warning, nil
+}
+
+func relativeFileReads(pathname string) ([]Warning, error) {
+	fileset := token.NewFileSet()
+	f, err := parser.ParseFile(fileset, pathname, nil, 0)
+	if err != nil {
+		// Best effort only.
+		return nil, nil
+	}
+	dir := filepath.Dir(pathname)
+	var warning []Warning
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, is := n.(*ast.CallExpr)
+		if !is || len(call.Args) == 0 {
+			return true
+		}
+		sel, is := call.Fun.(*ast.SelectorExpr)
+		if !is {
+			return true
+		}
+		x, is := sel.X.(*ast.Ident)
+		if !is || !fileReadFuncs[x.Name][sel.Sel.Name] {
+			return true
+		}
+		lit, is := call.Args[0].(*ast.BasicLit)
+		if !is || lit.Kind != token.STRING {
+			return true
+		}
+		name, err := strconv.Unquote(lit.Value)
+		if err != nil || len(name) == 0 || filepath.IsAbs(name) {
+			return true
+		}
+		pos := fileset.Position(call.Pos())
+		msg := fmt.Sprintf("line %d reads %q by relative path", pos.Line, name)
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			msg += ", which is not found next to the vendored package"
+		}
+		warning = append(warning, Warning{
+			Code:    WarnRelativeRead,
+			Message: msg,
+			Path:    pathname,
+		})
+		return true
+	})
+	return warning, nil
+}
@@ -6,6 +6,7 @@ package context
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/kardianos/govendor/internal/gt"
@@ -147,6 +148,46 @@ func TestUpdateOrigin(t *testing.T) {
 `)
 }
 
+func TestFetchRepoRootFunc(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("remote/co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("remote")
+	remote := gt.NewHttpHandler(g, "git")
+
+	g.In("remote/co2")
+	commitRev, _ := remote.Setup().Commit()
+
+	// The version control server has no HTTP meta lookup, like a private host.
+	root := remote.VcsAddr() + "/remote/co2"
+	defer func() { repoRootFuncs = nil }()
+	RegisterRepoRootFunc(func(importPath string) (string, bool) {
+		if strings.HasPrefix(importPath, root+"/") {
+			return root, true
+		}
+		return "", false
+	})
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1::"+root+"/pk1"), Fetch))
+	g.Check(c.Alter())
+
+	tree(g, "post", `
+/pk1/a.go
+/vendor/co2/pk1/a.go
+`)
+	if vp := c.VendorFilePackagePath("co2/pk1"); vp == nil || vp.Revision != commitRev {
+		t.Fatalf("got vendor package %v, want revision %q", vp, commitRev)
+	}
+}
+
 func TestFetchSub(t *testing.T) {
 	g := gt.New(t)
 	defer g.Clean()
@@ -205,6 +246,60 @@ func TestFetchSub(t *testing.T) {
 `)
 }
 
+func TestAddSubpackage(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("remote/co2/pk1",
+		gt.File("a.go", "bytes"),
+	)
+	g.Setup("remote/co2/pk1/pk2",
+		gt.File("a.go", "strings"),
+	)
+	g.In("remote")
+	remote := gt.NewHttpHandler(g, "git")
+
+	g.In("remote/co2")
+	commitRev1, _ := remote.Setup().Commit()
+
+	g.Setup("remote/co2/pk1/pk2",
+		gt.File("a.go", "strings", "bytes"),
+	)
+	remote.Setup().Commit()
+
+	remotePkg := remote.HttpAddr() + "/remote/co2/pk1"
+	g.Setup("co1/pk1",
+		gt.File("a.go", "remote/co2/pk1"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("remote/co2/pk1::"+remotePkg+"@"+commitRev1), Fetch))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "remote/co2/pk1", "remote/co2/pk1/pk2"),
+	)
+	c = ctx(g)
+	g.Check(c.AddSubpackage("remote/co2/pk1/pk2"))
+
+	tree(g, "post", `
+/pk1/a.go
+/vendor/remote/co2/pk1/a.go
+/vendor/remote/co2/pk1/pk2/a.go
+/vendor/vendor.json
+`)
+
+	c = ctx(g)
+	vp := c.VendorFilePackagePath("remote/co2/pk1/pk2")
+	if vp == nil {
+		t.Fatal("sub-package not in vendor file")
+	}
+	if vp.Revision != commitRev1 || vp.Origin != remotePkg+"/pk2" {
+		t.Fatalf("got revision %q origin %q, want revision %q origin %q", vp.Revision, vp.Origin, commitRev1, remotePkg+"/pk2")
+	}
+}
+
 func TestFetchAgain(t *testing.T) {
 	g := gt.New(t)
 	defer g.Clean()
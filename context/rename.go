@@ -0,0 +1,124 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kardianos/govendor/internal/pathos"
+)
+
+// renamePath returns p with the prefix from replaced by to.
+func renamePath(p, from, to string) (string, bool) {
+	if p == from {
+		return to, true
+	}
+	if strings.HasPrefix(p, from+"/") {
+		return to + p[len(from):], true
+	}
+	return p, false
+}
+
+// Rename changes the import path from, and any import path under it, to
+// the import path to in the whole project. Imports in project and vendor
+// packages are rewritten, vendor file entries have their path and origin
+// updated, and the vendor folder is moved to match.
+func (ctx *Context) Rename(from, to string) error {
+	from = strings.Trim(from, "/")
+	to = strings.Trim(to, "/")
+	if from == to {
+		return nil
+	}
+	if !ctx.loaded || ctx.dirty {
+		if err := ctx.loadPackage(); err != nil {
+			return err
+		}
+	}
+	vendorRoot := ctx.InternalPrefix()
+
+	// Check for a conflict before anything is changed, so a failed rename
+	// leaves the project as it was.
+	vendorDir := filepath.Join(ctx.RootDir, ctx.VendorFolder)
+	fromDir := filepath.Join(vendorDir, pathos.SlashToFilepath(from))
+	toDir := filepath.Join(vendorDir, pathos.SlashToFilepath(to))
+	_, err := os.Stat(fromDir)
+	moveDir := err == nil
+	if moveDir {
+		if _, err = os.Stat(toDir); err == nil {
+			return ErrPackageExists{path.Join(vendorRoot, to)}
+		}
+	}
+
+	rules := make(map[string]string)
+	for _, pkg := range ctx.Package {
+		for _, f := range pkg.Files {
+			for _, imp := range f.Imports {
+				if next, ok := renamePath(imp, from, to); ok {
+					rules[imp] = next
+				}
+				if next, ok := renamePath(imp, path.Join(vendorRoot, from), path.Join(vendorRoot, to)); ok {
+					rules[imp] = next
+				}
+			}
+		}
+	}
+	err = ctx.rewriteProject(rules)
+	if err != nil {
+		return err
+	}
+
+	if moveDir {
+		err = os.MkdirAll(filepath.Dir(toDir), 0777)
+		if err != nil {
+			return err
+		}
+		err = os.Rename(fromDir, toDir)
+		if err != nil {
+			return err
+		}
+		removeEmptyParents(filepath.Dir(fromDir), vendorDir)
+	}
+
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove {
+			continue
+		}
+		var renamed bool
+		vp.Path, renamed = renamePath(vp.Path, from, to)
+		vp.Origin, _ = renamePath(vp.Origin, from, to)
+		if !renamed || len(vp.ChecksumSHA1) == 0 {
+			continue
+		}
+		// The checksum includes the package path.
+		h := sha1.New()
+		sk := skipperPackage
+		if vp.Tree {
+			sk = skipperTree
+		}
+		err = getHash(vendorDir, filepath.Join(vendorDir, pathos.SlashToFilepath(vp.Path)), h, sk)
+		if err != nil {
+			return err
+		}
+		vp.ChecksumSHA1 = base64.StdEncoding.EncodeToString(h.Sum(nil))
+	}
+	ctx.dirty = true
+	return ctx.WriteVendorFile()
+}
+
+// removeEmptyParents removes dir and its parents while they are empty,
+// stopping at root.
+func removeEmptyParents(dir, root string) {
+	for pathos.FileHasPrefix(dir, root) && !pathos.FileStringEquals(dir, root) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/kardianos/govendor/internal/pathos"
+
+	"golang.org/x/tools/go/vcs"
+)
+
+// RepoRootFunc returns the repository root for an import path. If ok is
+// false the next function or the built-in rules are tried.
+type RepoRootFunc func(importPath string) (repoRoot string, ok bool)
+
+var repoRootFuncs []RepoRootFunc
+
+// RegisterRepoRootFunc adds a function that RepoRoot and fetching try
+// before the built-in rules, such as for private hosts with a custom layout.
+// Functions are tried in the order registered. Not safe to call
+// concurrently with RepoRoot or a fetch.
+func RegisterRepoRootFunc(f RepoRootFunc) {
+	repoRootFuncs = append(repoRootFuncs, f)
+}
+
+// RepoRoot returns the import path of the repository root that contains
+// the given import path. Registered RepoRootFuncs are tried first. Then well
+// known hosts are resolved from the path alone, otherwise the version control
+// system of the package in GOPATH is consulted. If nothing succeeds, the
+// import path itself is returned.
+func (ctx *Context) RepoRoot(importPath string) string {
+	for _, f := range repoRootFuncs {
+		if root, ok := f(importPath); ok {
+			return root
+		}
+	}
+	if root, ok := repoRootStatic(importPath); ok {
+		return root
+	}
+	dir, gopath, err := ctx.findImportDir("", importPath)
+	if err != nil || pathos.FileStringEquals(gopath, ctx.Goroot) {
+		return importPath
+	}
+	_, root, err := vcs.FromDir(dir, filepath.Clean(gopath))
+	if err != nil || len(root) == 0 {
+		return importPath
+	}
+	return pathos.SlashToImportPath(root)
+}
+
+// repoRootForImportPath finds the repository to fetch an import path
+// from. If a registered RepoRootFunc gives the repository root, the root
+// is looked up rather than the import path, and if that fails each
+// version control system is tried at the root. Otherwise the rules of the
+// vcs package are used. Insecure schemes are only tried if insecure is set.
+func repoRootForImportPath(importPath string, insecure bool) (*vcs.RepoRoot, error) {
+	for _, f := range repoRootFuncs {
+		root, ok := f(importPath)
+		if !ok {
+			continue
+		}
+		rr, err := vcs.RepoRootForImportPath(root, false)
+		if err == nil {
+			return rr, nil
+		}
+		for _, name := range []string{"git", "hg", "bzr", "svn"} {
+			cmd := vcs.ByCmd(name)
+			for _, scheme := range cmd.Scheme {
+				repo := scheme + "://" + root
+				if !insecure && !vcsIsSecure(repo) {
+					continue
+				}
+				if cmd.Ping(scheme, root) == nil {
+					return &vcs.RepoRoot{VCS: cmd, Repo: repo, Root: root}, nil
+				}
+			}
+		}
+		return nil, err
+	}
+	return vcs.RepoRootForImportPath(importPath, false)
+}
+
+// repoRootStatic determines the repository root for hosts with a known
+// layout.
+func repoRootStatic(importPath string) (string, bool) {
+	parts := strings.Split(importPath, "/")
+	take := 0
+	switch parts[0] {
+	case "github.com", "bitbucket.org", "gitlab.com", "golang.org":
+		take = 3
+	case "google.golang.org", "cloud.google.com":
+		take = 2
+	case "gopkg.in":
+		// gopkg.in/pkg.v1 or gopkg.in/user/pkg.v1
+		take = 3
+		if len(parts) > 1 && strings.Contains(parts[1], ".v") {
+			take = 2
+		}
+	}
+	if take == 0 || len(parts) < take {
+		return "", false
+	}
+	return strings.Join(parts[:take], "/"), true
+}
@@ -0,0 +1,223 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kardianos/govendor/internal/pathos"
+)
+
+// Unvendored returns the sorted import paths of external packages the
+// project uses that have not been copied into the vendor folder yet.
+// Excluded packages are not listed.
+func (ctx *Context) Unvendored() ([]string, error) {
+	list, err := ctx.Status()
+	if err != nil {
+		return nil, err
+	}
+	vendored := make(map[string]bool, len(list))
+	for _, item := range list {
+		if item.Status.Location == LocationVendor {
+			vendored[item.Pkg.Path] = true
+		}
+	}
+	found := make(map[string]bool, len(list))
+	out := make([]string, 0, 6)
+	for _, item := range list {
+		if item.Status.Location != LocationExternal || item.Status.Presence == PresenceExcluded {
+			continue
+		}
+		if vendored[item.Pkg.Path] || found[item.Pkg.Path] {
+			continue
+		}
+		found[item.Pkg.Path] = true
+		out = append(out, item.Pkg.Path)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// StdImports returns the sorted standard library packages used by the
+// project, including those used by vendored packages. The cgo pseudo
+// package "C" is not listed.
+func (ctx *Context) StdImports() ([]string, error) {
+	list, err := ctx.Status()
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(list))
+	out := make([]string, 0, 10)
+	for _, item := range list {
+		if item.Status.Location != LocationStandard || item.Pkg.Path == "C" || found[item.Pkg.Path] {
+			continue
+		}
+		found[item.Pkg.Path] = true
+		out = append(out, item.Pkg.Path)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// IsFullyVendored returns true if every package the project uses outside of
+// the standard library and the project itself is in the vendor folder.
+// Excluded packages are not counted.
+func (ctx *Context) IsFullyVendored() (bool, error) {
+	list, err := ctx.Unvendored()
+	if err != nil {
+		return false, err
+	}
+	return len(list) == 0, nil
+}
+
+// UnvendoredRepo returns the same packages as Unvendored grouped
+// by repository root.
+func (ctx *Context) UnvendoredRepo() (map[string][]string, error) {
+	list, err := ctx.Unvendored()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]string, len(list))
+	for _, p := range list {
+		root := ctx.RepoRoot(p)
+		out[root] = append(out[root], p)
+	}
+	return out, nil
+}
+
+// DependencyAudit compares the packages declared in the vendor file to the
+// packages the project uses.
+type DependencyAudit struct {
+	Unused     []string // Declared in the vendor file but not imported, removal candidates.
+	Undeclared []string // Imported but not declared in the vendor file, not yet vendored.
+}
+
+// DependencyAudit reports declared but unused and used but undeclared
+// packages, both sorted by import path. Excluded and missing packages
+// are not listed.
+func (ctx *Context) DependencyAudit() (DependencyAudit, error) {
+	var audit DependencyAudit
+	list, err := ctx.Status()
+	if err != nil {
+		return audit, err
+	}
+	found := make(map[string]bool, len(list))
+	for _, item := range list {
+		p := item.Pkg.Path
+		if found[p] || item.Status.Presence == PresenceExcluded || item.Status.Presence == PresenceMissing {
+			continue
+		}
+		switch item.Status.Location {
+		default:
+			continue
+		case LocationVendor:
+			declared := ctx.vendorFileCovers(p)
+			switch {
+			case declared && item.Status.Presence == PresenceUnused:
+				audit.Unused = append(audit.Unused, p)
+			case !declared && item.Status.Presence != PresenceUnused:
+				audit.Undeclared = append(audit.Undeclared, p)
+			default:
+				continue
+			}
+		case LocationExternal:
+			if ctx.vendorFileCovers(p) {
+				continue
+			}
+			audit.Undeclared = append(audit.Undeclared, p)
+		}
+		found[p] = true
+	}
+	sort.Strings(audit.Unused)
+	sort.Strings(audit.Undeclared)
+	return audit, nil
+}
+
+// VersionCoverage groups the packages in the vendor file by whether
+// version information was recorded for them.
+type VersionCoverage struct {
+	Versioned   []string // A version or revision is recorded, updates can be checked.
+	Unversioned []string // No version or revision is recorded, the vcs must be scanned again.
+}
+
+// VersionCoverage reports which packages in the vendor file have a
+// recorded version or revision, both sorted by import path.
+func (ctx *Context) VersionCoverage() VersionCoverage {
+	var cover VersionCoverage
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove {
+			continue
+		}
+		if len(vp.Version) > 0 || len(vp.Revision) > 0 {
+			cover.Versioned = append(cover.Versioned, vp.Path)
+		} else {
+			cover.Unversioned = append(cover.Unversioned, vp.Path)
+		}
+	}
+	sort.Strings(cover.Versioned)
+	sort.Strings(cover.Unversioned)
+	return cover
+}
+
+// NameClashes groups the packages in the vendor file by the last element
+// of their import path, such as "util", and returns the groups with more
+// than one package, each sorted by import path. Such packages build fine
+// but are easy to confuse when reading code.
+func (ctx *Context) NameClashes() map[string][]string {
+	byName := make(map[string][]string)
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove {
+			continue
+		}
+		name := path.Base(vp.Path)
+		byName[name] = append(byName[name], vp.Path)
+	}
+	out := make(map[string][]string)
+	for name, list := range byName {
+		if len(list) < 2 {
+			continue
+		}
+		sort.Strings(list)
+		out[name] = list
+	}
+	return out
+}
+
+// VendorStats holds aggregate counts for the vendor folder.
+type VendorStats struct {
+	Files   int   // Number of files, not counting the vendor file.
+	Bytes   int64 // Size of all files, not counting the vendor file.
+	GoFiles int   // Number of ".go" files.
+	GoBytes int64 // Size of all ".go" files.
+}
+
+// VendorStats counts the files and bytes in the vendor folder.
+func (ctx *Context) VendorStats() (VendorStats, error) {
+	var stats VendorStats
+	root := filepath.Join(ctx.RootDir, ctx.VendorFolder)
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == root {
+				return nil
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() || pathos.FileStringEquals(p, ctx.VendorFilePath) {
+			return nil
+		}
+		stats.Files++
+		stats.Bytes += info.Size()
+		if strings.HasSuffix(info.Name(), ".go") {
+			stats.GoFiles++
+			stats.GoBytes += info.Size()
+		}
+		return nil
+	})
+	return stats, err
+}
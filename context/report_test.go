@@ -0,0 +1,375 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kardianos/govendor/internal/gt"
+	"github.com/kardianos/govendor/vendorfile"
+)
+
+func TestUnvendored(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "github.com/u/r/a", "github.com/u/r/b", "strings"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("github.com/u/r/a",
+		gt.File("a.go", "github.com/u/r/b"),
+	)
+	g.Setup("github.com/u/r/b",
+		gt.File("a.go", "bytes"),
+	)
+	g.In("co1")
+	c := ctx(g)
+
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+
+	got, err := c.Unvendored()
+	g.Check(err)
+	want := []string{"github.com/u/r/a", "github.com/u/r/b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	repo, err := c.UnvendoredRepo()
+	g.Check(err)
+	wantRepo := map[string][]string{"github.com/u/r": want}
+	if !reflect.DeepEqual(repo, wantRepo) {
+		t.Fatalf("got %q, want %q", repo, wantRepo)
+	}
+}
+
+func TestVendorStats(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+		gt.File("b.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+
+	stats, err := c.VendorStats()
+	g.Check(err)
+	if stats != (VendorStats{}) {
+		t.Fatalf("got %+v for missing vendor folder", stats)
+	}
+
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	stats, err = c.VendorStats()
+	g.Check(err)
+	if stats.Files != 2 || stats.GoFiles != 2 || stats.Bytes == 0 || stats.Bytes != stats.GoBytes {
+		t.Fatalf("got %+v, want 2 go files", stats)
+	}
+}
+
+func TestRelativeFileReads(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1")
+	g.Setup("co2/pk1/testdata")
+	src := `package pk1
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+func A() {
+	os.Open("/abs/file")
+	ioutil.ReadFile("testdata/found.txt")
+	os.Open("missing.txt")
+}
+`
+	g.Check(ioutil.WriteFile(filepath.Join(g.Path("co2/pk1"), "a.go"), []byte(src), 0600))
+	g.Check(ioutil.WriteFile(filepath.Join(g.Path("co2/pk1/testdata"), "found.txt"), nil, 0600))
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+
+	warning, err := c.RelativeFileReads()
+	g.Check(err)
+	var got []string
+	for _, w := range warning {
+		if w.Code != WarnRelativeRead {
+			t.Fatalf("unexpected code %q", w.Code)
+		}
+		got = append(got, w.Message)
+	}
+	want := []string{
+		`line 10 reads "testdata/found.txt" by relative path`,
+		`line 11 reads "missing.txt" by relative path, which is not found next to the vendored package`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestRegisterRepoRootFunc(t *testing.T) {
+	defer func() { repoRootFuncs = nil }()
+	RegisterRepoRootFunc(func(importPath string) (string, bool) {
+		if strings.HasPrefix(importPath, "git.corp/") {
+			parts := strings.SplitN(importPath, "/", 5)
+			if len(parts) >= 4 {
+				return strings.Join(parts[:4], "/"), true
+			}
+		}
+		return "", false
+	})
+	c := &Context{}
+	for _, tc := range []struct{ in, want string }{
+		{"git.corp/team/sub/repo/pkg", "git.corp/team/sub/repo"},
+		{"github.com/u/r/a", "github.com/u/r"},
+	} {
+		if got := c.RepoRoot(tc.in); got != tc.want {
+			t.Errorf("RepoRoot(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestDependencyAudit(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co4/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co4/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.ModifyImport(pkg("co3/pk1"), Add))
+	g.Check(c.Alter())
+
+	got, err := c.DependencyAudit()
+	g.Check(err)
+	want := DependencyAudit{
+		Unused:     []string{"co3/pk1"},
+		Undeclared: []string{"co4/pk1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestIsFullyVendored(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "strings"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "co3/pk1"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+
+	full, err := c.IsFullyVendored()
+	g.Check(err)
+	if full {
+		t.Fatal("expected external packages before add")
+	}
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	full, err = c.IsFullyVendored()
+	g.Check(err)
+	if full {
+		t.Fatal("expected co3/pk1 to remain external")
+	}
+	g.Check(c.ModifyImport(pkg("co3/pk1"), Add))
+	g.Check(c.Alter())
+	full, err = c.IsFullyVendored()
+	g.Check(err)
+	if !full {
+		t.Fatal("expected fully vendored")
+	}
+}
+
+func TestVersionCoverage(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1", "co4/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co4/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.ModifyImport(pkg("co3/pk1"), Add))
+	g.Check(c.ModifyImport(pkg("co4/pk1"), Add))
+	g.Check(c.Alter())
+	c.VendorFilePackagePath("co2/pk1").Version = "v1"
+	c.VendorFilePackagePath("co4/pk1").Revision = "abc123"
+
+	got := c.VersionCoverage()
+	want := VersionCoverage{
+		Versioned:   []string{"co2/pk1", "co4/pk1"},
+		Unversioned: []string{"co3/pk1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestNameClashes(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	for _, p := range []string{"co2/util", "co3/pk1", "co4/util", "co5/x/util", "co6/pk2"} {
+		c.VendorFile.Package = append(c.VendorFile.Package, &vendorfile.Package{Path: p})
+	}
+	c.VendorFile.Package = append(c.VendorFile.Package, &vendorfile.Package{Path: "co7/pk2", Remove: true})
+
+	got := c.NameClashes()
+	want := map[string][]string{
+		"util": {"co2/util", "co4/util", "co5/x/util"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestParentHeaders(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1")
+	src := `package pk1
+
+// #include <stdio.h>
+// #include "local.h"
+// #include "../common.h"
+import "C"
+`
+	g.Check(ioutil.WriteFile(filepath.Join(g.Path("co2/pk1"), "a.go"), []byte(src), 0600))
+	g.Check(ioutil.WriteFile(filepath.Join(g.Path("co2/pk1"), "b.c"), []byte("#include \"../common.h\"\n"), 0600))
+	g.Check(ioutil.WriteFile(filepath.Join(g.Path("co2/pk1"), "local.h"), nil, 0600))
+	g.Check(ioutil.WriteFile(filepath.Join(g.Path("co2"), "common.h"), nil, 0600))
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	altered, err := c.AlterWarn()
+	g.Check(err)
+
+	warning, err := c.ParentHeaders()
+	g.Check(err)
+	want := []string{
+		`a.go: line 5 includes "../common.h" from outside the package, which must be vendored by hand`,
+		`b.c: line 1 includes "../common.h" from outside the package, which must be vendored by hand`,
+	}
+	for _, list := range [][]Warning{altered, warning} {
+		var got []string
+		for _, w := range list {
+			if w.Code != WarnParentHeader {
+				t.Fatalf("unexpected code %q", w.Code)
+			}
+			got = append(got, filepath.Base(w.Path)+": "+w.Message)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestParentHeadersLongLine(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	src := "static const char data[] = \"" + strings.Repeat("x", 100000) + "\";\n#include \"../common.h\""
+	g.Check(ioutil.WriteFile(filepath.Join(g.Path("co2/pk1"), "data.c"), []byte(src), 0600))
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	warning, err := c.AlterWarn()
+	g.Check(err)
+	if len(warning) != 1 || warning[0].Code != WarnParentHeader || !strings.HasPrefix(warning[0].Message, "line 2 ") {
+		t.Fatalf("got warnings %v", warning)
+	}
+}
+
+func TestStdImports(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "strings", "unsafe"),
+	)
+	g.Setup("co1/pk2",
+		gt.File("a.go", "strings", "C"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "bytes"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+
+	got, err := c.StdImports()
+	g.Check(err)
+	want := []string{"bytes", "strings", "unsafe"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
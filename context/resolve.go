@@ -6,12 +6,15 @@ package context
 
 import (
 	"go/ast"
+	"go/build"
 	"go/parser"
 	"go/token"
 	"path"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/kardianos/govendor/internal/pathos"
 	filepath "github.com/kardianos/govendor/internal/vfilepath"
@@ -37,6 +40,7 @@ func (ctx *Context) loadPackage() error {
 	ctx.loaded = true
 	ctx.dirty = false
 	ctx.statusCache = nil
+	ctx.loadErrors = nil
 	ctx.Package = make(map[string]*Package, len(ctx.Package))
 	// We following the root symlink only in case the root of the repo is symlinked into the GOPATH
 	// This could happen during on some CI that didn't checkout into the GOPATH
@@ -44,28 +48,24 @@ func (ctx *Context) loadPackage() error {
 	if err != nil {
 		return err
 	}
-	err = filepath.Walk(rootdir, func(path string, info os.FileInfo, err error) error {
+	walkdir := rootdir
+	if len(ctx.scopeDir) != 0 {
+		walkdir = filepath.Join(rootdir, ctx.scopeDir)
+	}
+	var files []string
+	err = filepath.Walk(walkdir, func(path string, info os.FileInfo, err error) error {
 		if info == nil {
-			return err
+			return ctx.loadError(err)
 		}
 		if !info.IsDir() {
 			// We replace the directory path (followed by the symlink), to the real go repo package name/path
 			// ex : replace "<somewhere>/govendor.source.repo" to "github.com/kardianos/govendor"
-			path = strings.Replace(path, rootdir, ctx.RootDir, 1)
-			_, err = ctx.addFileImports(path, ctx.RootGopath)
-			return err
-		}
-		name := info.Name()
-		// Still go into "_workspace" to aid godep migration.
-		if name == "_workspace" {
+			if strings.HasSuffix(path, ".go") {
+				files = append(files, strings.Replace(path, rootdir, ctx.RootDir, 1))
+			}
 			return nil
 		}
-		switch name[0] {
-		case '.', '_':
-			return filepath.SkipDir
-		}
-		switch name {
-		case "testdata", "node_modules":
+		if skipWalkDir(info.Name()) {
 			return filepath.SkipDir
 		}
 		return nil
@@ -73,8 +73,157 @@ func (ctx *Context) loadPackage() error {
 	if err != nil {
 		return err
 	}
+	// Parse files in parallel, then add them in walk order.
+	parsed := ctx.parseFiles(files)
+	for i, path := range files {
+		if ctx.hasFile(path) {
+			continue
+		}
+		_, err = ctx.addParsedFile(path, ctx.RootGopath, parsed[i].f, parsed[i].err)
+		if err = ctx.loadError(err); err != nil {
+			return err
+		}
+	}
 	// Finally, set any unset status.
-	return ctx.determinePackageStatus()
+	err = ctx.determinePackageStatus()
+	if err != nil {
+		return err
+	}
+	if len(ctx.loadErrors) != 0 {
+		return ctx.loadErrors
+	}
+	return nil
+}
+
+type parsedFile struct {
+	f   *ast.File
+	err error
+}
+
+// parseFiles parses the imports and comments of each file using up to
+// ctx.Workers goroutines.
+func (ctx *Context) parseFiles(files []string) []parsedFile {
+	parsed := make([]parsedFile, len(files))
+	workers := ctx.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	next := make(chan int)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers && w < len(files); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				f, err := parser.ParseFile(token.NewFileSet(), files[i], nil, parser.ImportsOnly|parser.ParseComments)
+				parsed[i] = parsedFile{f: f, err: err}
+			}
+		}()
+	}
+	for i := range files {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return parsed
+}
+
+// loadError records err and returns nil if errors are collected,
+// otherwise it returns err.
+func (ctx *Context) loadError(err error) error {
+	if err == nil || !ctx.CollectErrors {
+		return err
+	}
+	ctx.loadErrors = append(ctx.loadErrors, err)
+	return nil
+}
+
+// skipWalkDir reports if a directory is not looked in for packages.
+func skipWalkDir(name string) bool {
+	// Still go into "_workspace" to aid godep migration.
+	if name == "_workspace" {
+		return false
+	}
+	switch name[0] {
+	case '.', '_':
+		return true
+	}
+	switch name {
+	case "testdata", "node_modules":
+		return true
+	}
+	return false
+}
+
+// WalkPackages walks the project, including the vendor folder, and calls fn
+// for each package as soon as its directory and sub-directories have been
+// walked, rather than after the whole project is loaded. Packages are not
+// kept after fn returns, and their status is not yet known, as that needs
+// the whole project. If fn returns ErrStopWalk the walk stops and nil is
+// returned; any other error stops the walk and is returned.
+func (ctx *Context) WalkPackages(fn func(pkg *Package) error) error {
+	rootdir, err := filepath.EvalSymlinks(ctx.RootDir)
+	if err != nil {
+		return err
+	}
+	walkdir := rootdir
+	if len(ctx.scopeDir) != 0 {
+		walkdir = filepath.Join(rootdir, ctx.scopeDir)
+	}
+	// The walk replaces the loaded packages.
+	ctx.dirty = true
+	ctx.statusCache = nil
+	ctx.Package = make(map[string]*Package)
+
+	// Directories being walked, each ends with a path separator.
+	var open []string
+	// Pass on the package of every open directory that next is not in.
+	// A package may have been added before its directory was walked
+	// when imported by a package walked earlier.
+	flush := func(next string) error {
+		for len(open) > 0 {
+			dir := open[len(open)-1]
+			if len(next) > 0 && strings.HasPrefix(next, dir) {
+				return nil
+			}
+			open = open[:len(open)-1]
+			importPath := ctx.dirImportPath(dir, ctx.RootGopath)
+			pkg := ctx.Package[importPath]
+			if pkg == nil || len(pkg.Files) == 0 || !pathos.FileStringEquals(pkg.Dir, dir) {
+				continue
+			}
+			delete(ctx.Package, importPath)
+			if err := fn(pkg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err = filepath.Walk(walkdir, func(path string, info os.FileInfo, err error) error {
+		if info == nil {
+			return err
+		}
+		path = strings.Replace(path, rootdir, ctx.RootDir, 1)
+		if err := flush(path); err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			_, err = ctx.addFileImports(path, ctx.RootGopath)
+			return err
+		}
+		if skipWalkDir(info.Name()) {
+			return filepath.SkipDir
+		}
+		open = append(open, path+string(filepath.Separator))
+		return nil
+	})
+	if err == nil {
+		err = flush("")
+	}
+	if err == ErrStopWalk {
+		return nil
+	}
+	return err
 }
 
 func (ctx *Context) getFileTags(pathname string, f *ast.File) (tags *TagSet, imports []string, err error) {
@@ -120,15 +269,32 @@ func (ctx *Context) getFileTags(pathname string, f *ast.File) (tags *TagSet, imp
 		tags.AddFileTag(l[n-1])
 	}
 
+	// A "//go:build" line takes precedence over any "// +build" lines.
 	const buildPrefix = "// +build "
+	var plusBuild []string
+	hasGoBuild := false
 	for _, cc := range f.Comments {
 		for _, c := range cc.List {
+			if isGoBuild(c.Text) {
+				x, err := ParseGoBuild(c.Text)
+				if err != nil {
+					// Best effort, the go tool reports the syntax error.
+					continue
+				}
+				tags.AddBuildExpr(x)
+				hasGoBuild = true
+				continue
+			}
 			if strings.HasPrefix(c.Text, buildPrefix) {
-				text := strings.TrimPrefix(c.Text, buildPrefix)
-				tags.AddBuildTags(text)
+				plusBuild = append(plusBuild, strings.TrimPrefix(c.Text, buildPrefix))
 			}
 		}
 	}
+	if !hasGoBuild {
+		for _, text := range plusBuild {
+			tags.AddBuildTags(text)
+		}
+	}
 	imports = make([]string, 0, len(f.Imports))
 
 	for i := range f.Imports {
@@ -146,32 +312,48 @@ func (ctx *Context) getFileTags(pathname string, f *ast.File) (tags *TagSet, imp
 
 // addFileImports is called from loadPackage and resolveUnknown.
 func (ctx *Context) addFileImports(pathname, gopath string) (*Package, error) {
-	dir, filenameExt := filepath.Split(pathname)
-	importPath := pathos.FileTrimPrefix(dir, gopath)
-	importPath = pathos.SlashToImportPath(importPath)
-	importPath = strings.Trim(importPath, "/")
-
 	if !strings.HasSuffix(pathname, ".go") {
 		return nil, nil
 	}
 	// No need to add the same file more than once.
+	if ctx.hasFile(pathname) {
+		return nil, nil
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), pathname, nil, parser.ImportsOnly|parser.ParseComments)
+	return ctx.addParsedFile(pathname, gopath, f, err)
+}
+
+// hasFile reports if the file was already added or ignored.
+func (ctx *Context) hasFile(pathname string) bool {
+	dir, filenameExt := filepath.Split(pathname)
 	for _, pkg := range ctx.Package {
 		if !pathos.FileStringEquals(pkg.Dir, dir) {
 			continue
 		}
 		for _, f := range pkg.Files {
 			if pathos.FileStringEquals(f.Path, pathname) {
-				return nil, nil
+				return true
 			}
 		}
 		for _, f := range pkg.ignoreFile {
 			if pathos.FileStringEquals(f, filenameExt) {
-				return nil, nil
+				return true
 			}
 		}
 	}
-	// Ignore error here and continue on best effort.
-	f, _ := parser.ParseFile(token.NewFileSet(), pathname, nil, parser.ImportsOnly|parser.ParseComments)
+	return false
+}
+
+// addParsedFile adds a file parsed with its imports and comments. The
+// parse error, if any, is ignored unless errors are collected.
+func (ctx *Context) addParsedFile(pathname, gopath string, f *ast.File, parseErr error) (*Package, error) {
+	dir, filenameExt := filepath.Split(pathname)
+	importPath := ctx.dirImportPath(dir, gopath)
+
+	// Continue on best effort, unless errors are collected.
+	if parseErr != nil && ctx.CollectErrors {
+		ctx.loadErrors = append(ctx.loadErrors, parseErr)
+	}
 	if f == nil {
 		return nil, nil
 	}
@@ -205,6 +387,11 @@ func (ctx *Context) addFileImports(pathname, gopath string) (*Package, error) {
 		pkg = ctx.setPackage(dir, importPath, importPath, gopath, status)
 		ctx.Package[importPath] = pkg
 	}
+	// The declared name may legitimately differ from the directory name.
+	// Resolution always uses the import path, the name is only recorded.
+	if len(pkg.Name) == 0 {
+		pkg.Name = pkgNameNormalized
+	}
 	if pkg.Status.Location != LocationLocal {
 		if tags.IgnoreItem(ctx.ignoreTag...) {
 			pkg.ignoreFile = append(pkg.ignoreFile, filenameExt)
@@ -223,6 +410,19 @@ func (ctx *Context) addFileImports(pathname, gopath string) (*Package, error) {
 		Imports: make([]string, len(f.Imports)),
 	}
 	pkg.Files = append(pkg.Files, pf)
+	// Files that need cgo are not built without it, so their imports don't count.
+	// A package of only such files is still found, but is inactive.
+	needsCgo := !ctx.CgoEnabled && (tags.IgnoreItem("cgo") || importsC(f))
+	if !strings.HasSuffix(filenameExt, "_test.go") {
+		pkg.hasSource = true
+		if !needsCgo && !pkg.buildsHere && ctx.buildsOnPlatform(dir, filenameExt) {
+			pkg.buildsHere = true
+		}
+	}
+	if needsCgo {
+		pf.Imports = pf.Imports[:0]
+		return pkg, nil
+	}
 	for i := range f.Imports {
 		imp := f.Imports[i].Path.Value
 		imp, err = strconv.Unquote(imp)
@@ -271,6 +471,33 @@ func (ctx *Context) addFileImports(pathname, gopath string) (*Package, error) {
 	return pkg, nil
 }
 
+// dirImportPath returns the import path of a directory in gopath.
+func (ctx *Context) dirImportPath(dir, gopath string) string {
+	importPath := pathos.FileTrimPrefix(dir, gopath)
+	importPath = pathos.SlashToImportPath(importPath)
+	importPath = strings.Trim(importPath, "/")
+	return ctx.projectImportPath(importPath)
+}
+
+// buildsOnPlatform reports if the file would be built on the current
+// platform. Build constraints that can't be read are assumed to match.
+func (ctx *Context) buildsOnPlatform(dir, name string) bool {
+	bc := build.Default
+	bc.CgoEnabled = ctx.CgoEnabled
+	match, err := bc.MatchFile(dir, name)
+	return err != nil || match
+}
+
+// importsC reports if the file uses cgo.
+func importsC(f *ast.File) bool {
+	for _, imp := range f.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p == "C" {
+			return true
+		}
+	}
+	return false
+}
+
 func (ctx *Context) setPackage(dir, canonical, local, gopath string, status Status) *Package {
 	if pkg, exists := ctx.Package[local]; exists {
 		return pkg
@@ -421,15 +648,16 @@ func (ctx *Context) addSingleImport(pkgInDir, imp string, tree bool) (*Package,
 
 func (ctx *Context) determinePackageStatus() error {
 	// Add any packages in the vendor file but not in GOPATH or vendor dir.
+	// When scoped, only add vendor packages the scope references.
 	for _, vp := range ctx.VendorFile.Package {
-		if vp.Remove {
+		if vp.Remove || len(ctx.scopeDir) != 0 {
 			continue
 		}
 		if _, found := ctx.Package[vp.Path]; found {
 			continue
 		}
 		pkg, err := ctx.addSingleImport(ctx.RootDir, vp.Path, vp.Tree)
-		if err != nil {
+		if err = ctx.loadError(err); err != nil {
 			return err
 		}
 		if pkg != nil {
@@ -451,6 +679,13 @@ func (ctx *Context) determinePackageStatus() error {
 		pkg.Status.Location = LocationExternal
 	}
 
+	// Packages where no file builds on this platform are present but inactive.
+	for _, pkg := range ctx.Package {
+		if pkg.Status.Presence == PresenceFound && pkg.hasSource && !pkg.buildsHere {
+			pkg.Status.Presence = PresenceInactive
+		}
+	}
+
 	ctx.updatePackageReferences()
 
 	// Mark sub-tree packages as "tree", but leave any existing bit (unused) on the
@@ -500,7 +735,7 @@ func (ctx *Context) determinePackageStatus() error {
 	for i := 0; i <= looplimit; i++ {
 		altered := false
 		for path, pkg := range ctx.Package {
-			if pkg.Status.Presence == PresenceUnused || pkg.Status.Presence == PresenceTree || pkg.Status.Type == TypeProgram {
+			if pkg.Status.Presence == PresenceUnused || pkg.Status.Presence == PresenceTree || pkg.Status.Presence == PresenceInactive || pkg.Status.Type == TypeProgram {
 				continue
 			}
 			if len(pkg.referenced) > 0 || pkg.Status.Location != LocationVendor {
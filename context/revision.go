@@ -0,0 +1,48 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+
+	"github.com/kardianos/govendor/vcs"
+)
+
+// RevisionMismatch compares the revision recorded in the vendor file for
+// each package to the revision of its source in GOPATH, if the source is in
+// version control. A mismatch means the vendored copy is older or newer than
+// the source, or the recorded revision is wrong. Packages without a recorded
+// revision and sources with uncommitted changes are skipped.
+func (ctx *Context) RevisionMismatch() ([]Warning, error) {
+	var warning []Warning
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove || len(vp.Revision) == 0 {
+			continue
+		}
+		dir, gopath, err := ctx.findImportDir("", vp.PathOrigin())
+		if err != nil {
+			if _, is := err.(ErrNotInGOPATH); is {
+				continue
+			}
+			return nil, err
+		}
+		system, err := vcs.FindVcs(gopath, dir)
+		if err != nil {
+			if _, is := err.(vcs.ErrTimeout); is {
+				continue
+			}
+			return nil, err
+		}
+		if system == nil || system.Dirty || system.Revision == vp.Revision {
+			continue
+		}
+		warning = append(warning, Warning{
+			Code:    WarnRevisionMismatch,
+			Path:    vp.Path,
+			Message: fmt.Sprintf("vendor file records revision %q but the source in GOPATH is at %q", vp.Revision, system.Revision),
+		})
+	}
+	return warning, nil
+}
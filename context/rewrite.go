@@ -5,15 +5,19 @@
 package context
 
 import (
+	"bytes"
 	"go/ast"
 	"go/parser"
 	"go/printer"
 	"go/token"
+	"io/ioutil"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/dchest/safefile"
 	"github.com/kardianos/govendor/internal/pathos"
+	filepath "github.com/kardianos/govendor/internal/vfilepath"
 	os "github.com/kardianos/govendor/internal/vos"
 )
 
@@ -42,25 +46,25 @@ func (ctx *Context) rewrite() error {
 			}
 		}
 	}
-	filePaths := make(map[string]*File, len(ctx.RewriteRule))
+	filePaths := make(map[string]*File, len(ctx.RewriteRule)) // map[pathos.FileKey]File, each file is rewritten once
 	for from, to := range ctx.RewriteRule {
 		// Add files that contain an import path to rewrite.
 		for _, f := range fileImports[from] {
-			filePaths[f.Path] = f
+			filePaths[pathos.FileKey(f.Path)] = f
 		}
 
 		// Add files that contain import comments to remove.
 		if pkg := ctx.Package[from]; pkg != nil {
 			for _, f := range pkg.Files {
 				if len(f.ImportComment) != 0 {
-					filePaths[f.Path] = f
+					filePaths[pathos.FileKey(f.Path)] = f
 				}
 			}
 		}
 		if pkg := ctx.Package[to]; pkg != nil {
 			for _, f := range pkg.Files {
 				if len(f.ImportComment) != 0 {
-					filePaths[f.Path] = f
+					filePaths[pathos.FileKey(f.Path)] = f
 				}
 			}
 		}
@@ -86,7 +90,7 @@ func (ctx *Context) rewrite() error {
 		for _, ref := range pkg.referenced {
 			for _, f := range ref.Files {
 				dprintf("REF RW %s\n", f.Path)
-				filePaths[f.Path] = f
+				filePaths[pathos.FileKey(f.Path)] = f
 			}
 		}
 	}
@@ -98,98 +102,335 @@ func (ctx *Context) rewrite() error {
 	if len(ctx.RewriteRule) == 0 {
 		return nil
 	}
-	goprint := &printer.Config{
-		Mode:     printer.TabIndent | printer.UseSpaces,
-		Tabwidth: 8,
-	}
+	rewritten := make(map[string]int, len(ctx.RewriteRule)) // map[from]file count
+	vendorDir := filepath.Join(ctx.RootDir, ctx.VendorFolder) + string(filepath.Separator)
 	for _, fileInfo := range filePaths {
 		if !pathos.FileHasPrefix(fileInfo.Path, ctx.RootDir) {
 			continue
 		}
+		dprintf("RW:: File: %s\n", fileInfo.Path)
 
-		// Read the file into AST, modify the AST.
-		fileset := token.NewFileSet()
-		f, _ := parser.ParseFile(fileset, fileInfo.Path, nil, parser.ParseComments)
-		if f == nil {
-			return nil
+		// Remove import comment. A vendor folder that is not named "vendor"
+		// is not found as vendor, so also check the file location; import
+		// comments are common on versioned paths such as gopkg.in.
+		st := fileInfo.Package.Status
+		dropImportComment := st.Location == LocationVendor || st.Location == LocationExternal ||
+			pathos.FileHasPrefix(fileInfo.Path, vendorDir)
+
+		changed, err := rewriteFile(fileInfo.Path, ctx.RewriteRule, dropImportComment, ctx.BackupRewrite)
+		if err != nil {
+			return err
 		}
-		pkgNameNormalized := strings.TrimSuffix(f.Name.Name, "_test")
-		// Files with package name "documentation" should be ignored, per go build tool.
-		if pkgNameNormalized == "documentation" {
-			return nil
+		for i, metaImport := range fileInfo.Imports {
+			if to, found := ctx.RewriteRule[metaImport]; found {
+				dprintf("\tImport: %s -> %s\n", metaImport, to)
+				fileInfo.Imports[i] = to
+				if changed {
+					rewritten[metaImport]++
+				}
+			}
+		}
+	}
+	if ctx.verbose(1) {
+		fromList := make([]string, 0, len(rewritten))
+		for from := range rewritten {
+			fromList = append(fromList, from)
 		}
+		sort.Strings(fromList)
+		for _, from := range fromList {
+			ctx.logf(1, "rewriting %s in %d files\n", from, rewritten[from])
+		}
+	}
+	return nil
+}
 
-		dprintf("RW:: File: %s\n", fileInfo.Path)
+// Rewrite rewrites imports of a vendored package in the project to the
+// vendored path without copying the package again. Use it to finish a
+// rewrite that was interrupted after the package was copied. Sub-packages
+// of a tree package are rewritten too. Does nothing if the context does
+// not rewrite imports.
+func (ctx *Context) Rewrite(importPath string) error {
+	vp := ctx.VendorFilePackagePath(importPath)
+	if vp == nil {
+		return ErrNotVendored{Path: importPath}
+	}
+	if !ctx.rewriteImports {
+		return nil
+	}
+	if _, err := ctx.Status(); err != nil {
+		return err
+	}
+	prefix := ctx.InternalPrefix() + "/"
+	rules := make(map[string]string, 3)
+	for local := range ctx.Package {
+		if !strings.HasPrefix(local, prefix) {
+			continue
+		}
+		from := strings.TrimPrefix(local, prefix)
+		if from == vp.Path || (vp.Tree && strings.HasPrefix(from, vp.Path+"/")) {
+			rules[from] = local
+		}
+	}
+	return ctx.rewriteProject(rules)
+}
 
-		for _, impNode := range f.Imports {
-			imp, err := strconv.Unquote(impNode.Path.Value)
-			if err != nil {
-				return err
+// rewriteProject rewrites imports that exactly match a rule in every
+// file of the project, including files in the vendor folder.
+func (ctx *Context) rewriteProject(rules map[string]string) error {
+	if !ctx.loaded || ctx.dirty {
+		if err := ctx.loadPackage(); err != nil {
+			return err
+		}
+	}
+	ctx.dirty = true
+	// A file listed twice must be rewritten once, or chained rules
+	// would be applied twice.
+	done := make(map[string]bool)
+	for _, pkg := range ctx.Package {
+		if !pathos.FileHasPrefix(pkg.Dir, ctx.RootDir) {
+			continue
+		}
+	fileLoop:
+		for _, f := range pkg.Files {
+			key := pathos.FileKey(f.Path)
+			if done[key] {
+				continue
 			}
-			for from, to := range ctx.RewriteRule {
-				if imp != from {
+			for _, imp := range f.Imports {
+				if _, found := rules[imp]; !found {
 					continue
 				}
-				impNode.Path.Value = strconv.Quote(to)
-				for i, metaImport := range fileInfo.Imports {
-					if from == metaImport {
-						dprintf("\tImport: %s -> %s\n", from, to)
-						fileInfo.Imports[i] = to
-					}
+				done[key] = true
+				if _, err := rewriteFile(f.Path, rules, false, ctx.BackupRewrite); err != nil {
+					return err
 				}
-				break
+				continue fileLoop
 			}
 		}
+	}
+	return nil
+}
 
-		// Remove import comment.
-		st := fileInfo.Package.Status
-		if st.Location == LocationVendor || st.Location == LocationExternal {
-			var ic *ast.Comment
-			if f.Name != nil {
-				pos := f.Name.Pos()
-			big:
-				// Find the next comment after the package name.
-				for _, cblock := range f.Comments {
-					for _, c := range cblock.List {
-						if c.Pos() > pos {
-							ic = c
-							break big
-						}
-					}
-				}
-			}
-			if ic != nil {
-				// If it starts with the import text, assume it is the import comment and remove.
-				if index := strings.Index(ic.Text, " import "); index > 0 && index < 5 {
-					ic.Text = strings.Repeat(" ", len(ic.Text))
-				}
-			}
-		}
+// Rule rewrites imports of the path From to the path To.
+type Rule struct {
+	From string
+	To   string
+}
 
-		// Don't sort or modify the imports to minimize diffs.
+// RewriteFile rewrites the imports of a single file that exactly match the
+// From path of a rule. The file is written atomically, using a temporary
+// file that is renamed, and only if it changed. If more than one rule has
+// the same From path the last one is used.
+func RewriteFile(pathname string, rules []Rule) (changed bool, err error) {
+	return rewriteFile(pathname, ruleMap(rules), false, false)
+}
 
-		// Write the AST back to disk.
-		fi, err := os.Stat(fileInfo.Path)
+// RewriteFileDiff returns the changes RewriteFile would make to the file
+// as a unified diff, without writing the file. Returns an empty string if
+// the file would not change.
+func RewriteFileDiff(pathname string, rules []Rule) (string, error) {
+	return rewriteDiff(pathname, ruleMap(rules))
+}
+
+func ruleMap(rules []Rule) map[string]string {
+	m := make(map[string]string, len(rules))
+	for _, r := range rules {
+		m[r.From] = r.To
+	}
+	return m
+}
+
+// rewriteDiff returns the unified diff of rewriting the imports of a single
+// file, see rewriteFile. Nothing is written.
+func rewriteDiff(pathname string, rules map[string]string) (string, error) {
+	src, err := ioutil.ReadFile(pathname)
+	if err != nil {
+		return "", err
+	}
+	out, changed, err := rewriteSource(pathname, src, rules, false)
+	if err != nil || !changed {
+		return "", err
+	}
+	return unifiedDiff(pathname, src, out), nil
+}
+
+// rewriteFile rewrites the imports of a single file that exactly match
+// a rule in rules. If dropImportComment is true any import comment is
+// blanked out. The file is only written if it changed. If backup is true
+// the original file is first copied next to it with a backupExt suffix,
+// unless a backup already exists.
+func rewriteFile(pathname string, rules map[string]string, dropImportComment, backup bool) (changed bool, err error) {
+	src, err := ioutil.ReadFile(pathname)
+	if err != nil {
+		return false, err
+	}
+	out, changed, err := rewriteSource(pathname, src, rules, dropImportComment)
+	if err != nil || !changed {
+		return false, err
+	}
+
+	// Write the AST back to disk.
+	fi, err := os.Stat(pathname)
+	if err != nil {
+		return false, err
+	}
+	if backup {
+		// Keep an existing backup, it has the file before the first rewrite.
+		_, err = os.Stat(pathname + backupExt)
+		if os.IsNotExist(err) {
+			err = ioutil.WriteFile(pathname+backupExt, src, fi.Mode())
+		}
 		if err != nil {
-			return err
+			return false, err
 		}
-		w, err := safefile.Create(fileInfo.Path, fi.Mode())
+	}
+	w, err := safefile.Create(pathname, fi.Mode())
+	if err != nil {
+		return false, err
+	}
+	_, err = w.Write(out)
+	if err != nil {
+		w.Close()
+		return false, err
+	}
+	return true, w.Commit()
+}
+
+// backupExt is added to the name of a file backed up before a rewrite.
+// It is specific to govendor so backups made by hand are not removed.
+const backupExt = ".govendor.bak"
+
+// RemoveRewriteBackups removes the backups of Go files left by rewriting
+// imports with BackupRewrite set, from the project and vendor folders.
+// Other ".bak" files are kept. It returns the paths of the removed files.
+func (ctx *Context) RemoveRewriteBackups() ([]string, error) {
+	var removed []string
+	err := filepath.Walk(ctx.RootDir, func(p string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		err = goprint.Fprint(w, fileset, f)
-		if err != nil {
-			w.Close()
+		if info.IsDir() {
+			if p != ctx.RootDir && skipWalkDir(info.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(info.Name(), ".go"+backupExt) {
+			return nil
+		}
+		if err := os.Remove(p); err != nil {
 			return err
 		}
-		err = w.Commit()
+		removed = append(removed, p)
+		return nil
+	})
+	return removed, err
+}
+
+// rewriteSource rewrites the imports of the source of a single file, see
+// rewriteFile. The pathname is only used in errors. Returns the rewritten
+// source if it changed.
+func rewriteSource(pathname string, src []byte, rules map[string]string, dropImportComment bool) (out []byte, changed bool, err error) {
+	// Read the file into AST, modify the AST.
+	fileset := token.NewFileSet()
+	f, parseErr := parser.ParseFile(fileset, pathname, src, parser.ParseComments)
+	if f == nil {
+		return nil, false, nil
+	}
+	pkgNameNormalized := strings.TrimSuffix(f.Name.Name, "_test")
+	// Files with package name "documentation" should be ignored, per go build tool.
+	if pkgNameNormalized == "documentation" {
+		return nil, false, nil
+	}
+
+	for _, impNode := range f.Imports {
+		imp, err := strconv.Unquote(impNode.Path.Value)
 		if err != nil {
-			return err
+			return nil, false, err
+		}
+		// Only the path is replaced, any import name such as a dot,
+		// blank or rename import is kept as is.
+		if to, found := rules[imp]; found {
+			impNode.Path.Value = strconv.Quote(to)
+			changed = true
+		}
+	}
+
+	if dropImportComment {
+		var ic *ast.Comment
+		if f.Name != nil {
+			pos := f.Name.Pos()
+		big:
+			// Find the next comment after the package name.
+			for _, cblock := range f.Comments {
+				for _, c := range cblock.List {
+					if c.Pos() > pos {
+						ic = c
+						break big
+					}
+				}
+			}
+		}
+		if ic != nil {
+			// If it starts with the import text, assume it is the import comment and remove.
+			if index := strings.Index(ic.Text, " import "); index > 0 && index < 5 {
+				ic.Text = strings.Repeat(" ", len(ic.Text))
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return nil, false, nil
+	}
+
+	// Don't sort or modify the imports to minimize diffs.
+
+	goprint := &printer.Config{
+		Mode:     printer.TabIndent | printer.UseSpaces,
+		Tabwidth: 8,
+	}
+	buf := &bytes.Buffer{}
+	err = goprint.Fprint(buf, fileset, f)
+	if err != nil {
+		return nil, false, err
+	}
+	err = verifyRewrite(pathname, src, buf.Bytes(), parseErr == nil)
+	if err != nil {
+		return nil, false, err
+	}
+	return buf.Bytes(), true, nil
+}
+
+// verifyRewrite checks the rewritten source before it is written, so a
+// rewrite bug never leaves broken Go behind. If the source parsed, the
+// rewritten source must also parse. Only import specs may change, so the
+// package clause must be the same.
+func verifyRewrite(pathname string, src, out []byte, srcParsed bool) error {
+	if srcParsed {
+		if _, err := parser.ParseFile(token.NewFileSet(), pathname, out, parser.ParseComments); err != nil {
+			return ErrRewriteInvalid{Path: pathname, Err: err}
 		}
 	}
+	before, after := packageClause(src), packageClause(out)
+	if before != after {
+		return ErrPackageClauseChanged{Path: pathname, Before: before, After: after}
+	}
 	return nil
 }
 
+// packageClause returns the source of the package clause, from the
+// "package" keyword to the end of the package name. Runs of white space
+// are collapsed, as printing the file may change them.
+func packageClause(src []byte) string {
+	fileset := token.NewFileSet()
+	f, _ := parser.ParseFile(fileset, "", src, parser.PackageClauseOnly)
+	if f == nil || f.Name == nil {
+		return ""
+	}
+	clause := src[fileset.Position(f.Package).Offset:fileset.Position(f.Name.End()).Offset]
+	return strings.Join(strings.Fields(string(clause)), " ")
+}
+
 func (ctx *Context) makeSet(pkg *Package, mvSet map[*Package]struct{}) {
 	mvSet[pkg] = struct{}{}
 	for _, f := range pkg.Files {
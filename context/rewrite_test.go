@@ -0,0 +1,316 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRewriteFileNamedImports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "govendor-rewrite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	const from = `package a
+
+import (
+	. "old/dot"
+	_ "old/blank"
+	n "old/named"
+	"old/plain"
+)
+`
+	const to = `package a
+
+import (
+	. "new/dot"
+	_ "new/blank"
+	n "new/named"
+	"new/plain"
+)
+`
+	p := filepath.Join(dir, "a.go")
+	err = ioutil.WriteFile(p, []byte(from), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := rewriteFile(p, map[string]string{
+		"old/dot":   "new/dot",
+		"old/blank": "new/blank",
+		"old/named": "new/named",
+		"old/plain": "new/plain",
+	}, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Fatal("file not changed")
+	}
+	got, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != to {
+		t.Fatalf("Got:\n%s\nWant:\n%s", got, to)
+	}
+}
+
+func TestRewriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "govendor-rewrite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	const from = `package  a
+
+import (
+	"old/a"
+	"strings"
+)
+`
+	const to = `package a
+
+import (
+	"new/a"
+	"strings"
+)
+`
+	p := filepath.Join(dir, "a.go")
+	err = ioutil.WriteFile(p, []byte(from), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := []Rule{{From: "old/a", To: "new/a"}}
+	changed, err := RewriteFile(p, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Fatal("file not changed")
+	}
+	got, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != to {
+		t.Fatalf("got\n%s\nwant\n%s", got, to)
+	}
+
+	// Nothing left to rewrite.
+	changed, err = RewriteFile(p, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed {
+		t.Fatal("file changed on second rewrite")
+	}
+}
+
+func TestRewriteFileDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "govendor-rewrite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	const from = `package a
+
+import (
+	"bytes"
+	"old/a"
+	"strings"
+)
+
+var _ = bytes.MinRead
+`
+	p := filepath.Join(dir, "a.go")
+	err = ioutil.WriteFile(p, []byte(from), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff, err := RewriteFileDiff(p, []Rule{{From: "old/a", To: "new/a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--- " + p + "\n+++ " + p + `
+@@ -2,7 +2,7 @@
+ 
+ import (
+ 	"bytes"
+-	"old/a"
++	"new/a"
+ 	"strings"
+ )
+ 
+`
+	if diff != want {
+		t.Fatalf("got\n%s\nwant\n%s", diff, want)
+	}
+	got, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != from {
+		t.Fatal("file written")
+	}
+
+	diff, err = RewriteFileDiff(p, []Rule{{From: "other/a", To: "new/a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != "" {
+		t.Fatalf("got diff for unchanged file\n%s", diff)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n"
+	b := "1\nA\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\nB\n"
+	const want = `--- f
++++ f
+@@ -1,5 +1,5 @@
+ 1
+-2
++A
+ 3
+ 4
+ 5
+@@ -14,3 +14,4 @@
+ 14
+ 15
+ 16
++B
+`
+	if got := unifiedDiff("f", []byte(a), []byte(b)); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPackageClause(t *testing.T) {
+	list := []struct {
+		Src    string
+		Clause string
+	}{
+		{"package a\n", "package a"},
+		{"// Doc.\npackage  b // import \"x/b\"\n\nimport \"strings\"\n", "package b"},
+		{"/* c */ package /* d */ c_test\n", "package /* d */ c_test"},
+		{"not go", ""},
+	}
+	for _, item := range list {
+		if got := packageClause([]byte(item.Src)); got != item.Clause {
+			t.Errorf("for %q got %q, want %q", item.Src, got, item.Clause)
+		}
+	}
+}
+
+func TestVerifyRewrite(t *testing.T) {
+	const src = "package a\n\nimport \"old/a\"\n"
+	list := []struct {
+		Out       string
+		SrcParsed bool
+		Err       string
+	}{
+		{"package a\n\nimport \"new/a\"\n", true, ""},
+		{"package a\n\nimport \"new/a\n", true, "ErrRewriteInvalid"},
+		{"package a\n\nimport \"new/a\n", false, ""},
+		{"package b\n\nimport \"new/a\"\n", true, "ErrPackageClauseChanged"},
+	}
+	for i, item := range list {
+		err := verifyRewrite("a.go", []byte(src), []byte(item.Out), item.SrcParsed)
+		got := ""
+		switch err.(type) {
+		case nil:
+		case ErrRewriteInvalid:
+			got = "ErrRewriteInvalid"
+		case ErrPackageClauseChanged:
+			got = "ErrPackageClauseChanged"
+		default:
+			t.Fatalf("%d: unexpected error %v", i, err)
+		}
+		if got != item.Err {
+			t.Errorf("%d: got %q, want %q", i, got, item.Err)
+		}
+	}
+}
+
+func TestCopyFileRewrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "govendor-rewrite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	const from = `package a // import "old/a"
+
+import "old/b"
+`
+	const to = `package a
+
+import "new/b"
+`
+	src := filepath.Join(dir, "src.go")
+	dest := filepath.Join(dir, "dest.go")
+	err = ioutil.WriteFile(src, []byte(from), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := sha1.New()
+	err = copyFile(dest, src, h, nil, map[string]string{"old/b": "new/b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Replace(string(got), " ", "", -1) != strings.Replace(to, " ", "", -1) {
+		t.Fatalf("got:\n%s", got)
+	}
+	// The checksum is of the file before it was rewritten.
+	if want := sha1.Sum([]byte(from)); !bytes.Equal(h.Sum(nil), want[:]) {
+		t.Fatal("checksum is not of the source file")
+	}
+}
+
+func TestRewriteProjectOnce(t *testing.T) {
+	dir, err := ioutil.TempDir("", "govendor-rewrite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := filepath.Join(dir, "a.go")
+	err = ioutil.WriteFile(p, []byte("package a\n\nimport \"old/a\"\n"), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same file is listed by two packages, once by an unclean path.
+	ctx := &Context{RootDir: dir, loaded: true}
+	ctx.Package = map[string]*Package{
+		"a":  {Dir: dir, Files: []*File{{Path: p, Imports: []string{"old/a"}}}},
+		"a2": {Dir: dir, Files: []*File{{Path: filepath.Join(dir, ".", "x", "..", "a.go"), Imports: []string{"old/a"}}}},
+	}
+	// Rewriting twice would chain the rules.
+	err = ctx.rewriteProject(map[string]string{"old/a": "new/a", "new/a": "newer/a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), `"new/a"`) {
+		t.Fatalf("got:\n%s", got)
+	}
+}
@@ -84,6 +84,8 @@ func (s Status) String() string {
 		p = 't'
 	case PresenceExcluded:
 		p = 'x'
+	case PresenceInactive:
+		p = 'i'
 	}
 	return not + string(t) + string(l) + string(p)
 }
@@ -176,6 +178,7 @@ const (
 	PresenceUnused                         // PresenceUnused package is found locally but not referenced.
 	PresenceTree                           // PresenceTree package is in vendor folder, in a tree, but not referenced.
 	PresenceExcluded                       // PresenceExcluded package exists, but should not be vendored.
+	PresenceInactive                       // PresenceInactive package exists, but no files build on this platform.
 )
 
 // ListItem represents a package in the current project.
@@ -184,7 +187,17 @@ type StatusItem struct {
 	Pkg          *pkgspec.Pkg
 	VersionExact string
 	Local        string
+	Name         string // Declared package name.
 	ImportedBy   []*Package
+
+	// ImportedByFile lists the files that import a missing package, so
+	// the import may be found. Only set for missing packages.
+	ImportedByFile []string
+
+	// Direct is true if at least one importer is outside of a vendor folder.
+	// Vendor packages that are not direct are only imported by other
+	// vendor packages.
+	Direct bool
 }
 
 func (li StatusItem) String() string {
@@ -205,6 +218,63 @@ func (li statusItemSort) Less(i, j int) bool {
 	return li[i].Local < li[j].Local
 }
 
+// vendorFileOrderSort orders items by their index in the vendor file.
+type vendorFileOrderSort struct {
+	list  []StatusItem
+	index []int
+}
+
+func (s vendorFileOrderSort) Len() int { return len(s.list) }
+func (s vendorFileOrderSort) Swap(i, j int) {
+	s.list[i], s.list[j] = s.list[j], s.list[i]
+	s.index[i], s.index[j] = s.index[j], s.index[i]
+}
+func (s vendorFileOrderSort) Less(i, j int) bool { return s.index[i] < s.index[j] }
+
+// VendorFileOrder returns a copy of the status list with vendor packages in
+// the order they appear in the vendor file, so the list may be compared to
+// the vendor file directly. Other packages follow in their existing order.
+func (ctx *Context) VendorFileOrder(list []StatusItem) []StatusItem {
+	position := make(map[string]int, len(ctx.VendorFile.Package))
+	for i, vp := range ctx.VendorFile.Package {
+		if _, found := position[vp.Path]; !found {
+			position[vp.Path] = i
+		}
+	}
+	s := vendorFileOrderSort{
+		list:  append([]StatusItem(nil), list...),
+		index: make([]int, len(list)),
+	}
+	for i, item := range s.list {
+		s.index[i] = len(ctx.VendorFile.Package)
+		if item.Status.Location != LocationVendor {
+			continue
+		}
+		if p, found := position[item.Pkg.Path]; found {
+			s.index[i] = p
+		}
+	}
+	sort.Stable(s)
+	return s.list
+}
+
+// importingFiles returns the sorted paths of files that import pkg.
+func importingFiles(pkg *Package) []string {
+	var files []string
+	for _, ref := range pkg.referenced {
+		for _, f := range ref.Files {
+			for _, imp := range f.Imports {
+				if imp == pkg.Local || imp == pkg.Path {
+					files = append(files, f.Path)
+					break
+				}
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
 // Status obtains the current package status list.
 func (ctx *Context) updateStatusCache() error {
 	var err error
@@ -236,13 +306,20 @@ func (ctx *Context) updateStatusCache() error {
 			Status:       pkg.Status,
 			Pkg:          &pkgspec.Pkg{Path: pkg.Path, IncludeTree: pkg.IncludeTree, Origin: origin, Version: version, FilePath: pkg.Dir},
 			Local:        pkg.Local,
+			Name:         pkg.Name,
 			VersionExact: versionExact,
 			ImportedBy:   make([]*Package, 0, len(pkg.referenced)),
 		}
 		for _, ref := range pkg.referenced {
 			li.ImportedBy = append(li.ImportedBy, ref)
+			if !ref.inVendor {
+				li.Direct = true
+			}
 		}
 		sort.Sort(packageList(li.ImportedBy))
+		if pkg.Status.Presence == PresenceMissing {
+			li.ImportedByFile = importingFiles(pkg)
+		}
 		list = append(list, li)
 	}
 	// Sort li by Status, then Path.
@@ -269,3 +346,29 @@ func (ctx *Context) Status() ([]StatusItem, error) {
 	}
 	return ctx.statusCache, nil
 }
+
+// StatusInfo describes a single status that may be used as a filter.
+type StatusInfo struct {
+	Status      Status
+	Letter      rune   // Letter used in the status column and as a short filter name.
+	Name        string // Filter name, used as "+name".
+	Description string
+}
+
+// AllStatuses returns every status that may be used as a filter, grouped by
+// location, presence, then type.
+func AllStatuses() []StatusInfo {
+	return []StatusInfo{
+		{Status{Location: LocationLocal}, 'l', "local", "packages in your project"},
+		{Status{Location: LocationExternal}, 'e', "external", "referenced packages in GOPATH but not in current project"},
+		{Status{Location: LocationVendor}, 'v', "vendor", "packages in the vendor folder"},
+		{Status{Location: LocationStandard}, 's', "std", "packages in the standard library"},
+
+		{Status{Presence: PresenceExcluded}, 'x', "excluded", "external packages explicitly excluded from vendoring"},
+		{Status{Presence: PresenceUnused}, 'u', "unused", "packages in the vendor folder, but unused"},
+		{Status{Presence: PresenceMissing}, 'm', "missing", "referenced packages but not found"},
+		{Status{Presence: PresenceInactive}, 'i', "inactive", "packages with no files that build on this platform"},
+
+		{Status{Type: TypeProgram}, 'p', "program", "package is a main package"},
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/kardianos/govendor/pkgspec"
+	"github.com/kardianos/govendor/vendorfile"
+)
+
+// VendorParent returns the vendor file package that importPath is a
+// sub-package of. If there is more than one, the longest is returned.
+// Returns nil if importPath is not under a vendored package.
+func (ctx *Context) VendorParent(importPath string) *vendorfile.Package {
+	var parent *vendorfile.Package
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove || !strings.HasPrefix(importPath, vp.Path+"/") {
+			continue
+		}
+		if parent == nil || len(vp.Path) > len(parent.Path) {
+			parent = vp
+		}
+	}
+	return parent
+}
+
+// MissingSubpackages returns missing imports that are sub-packages of an
+// already vendored package.
+func (ctx *Context) MissingSubpackages() ([]string, error) {
+	list, err := ctx.Status()
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool)
+	var missing []string
+	for _, item := range list {
+		if item.Status.Presence != PresenceMissing || found[item.Pkg.Path] {
+			continue
+		}
+		if ctx.VendorParent(item.Pkg.Path) == nil {
+			continue
+		}
+		found[item.Pkg.Path] = true
+		missing = append(missing, item.Pkg.Path)
+	}
+	sort.Strings(missing)
+	return missing, nil
+}
+
+// AddSubpackage fetches a sub-package of an already vendored package into
+// the vendor folder at the revision of the vendored package and records
+// it in the vendor file.
+func (ctx *Context) AddSubpackage(importPath string) error {
+	parent := ctx.VendorParent(importPath)
+	if parent == nil {
+		return ErrNotVendored{Path: importPath}
+	}
+	ps := &pkgspec.Pkg{Path: importPath}
+	if len(parent.Origin) > 0 {
+		ps.Origin = parent.Origin + strings.TrimPrefix(importPath, parent.Path)
+		ps.HasOrigin = true
+	}
+	if len(parent.Revision) > 0 {
+		ps.Version = parent.Revision
+		ps.HasVersion = true
+	}
+	err := ctx.ModifyImport(ps, Fetch)
+	if err != nil {
+		return err
+	}
+	err = ctx.Alter()
+	vferr := ctx.WriteVendorFile()
+	if err != nil {
+		return err
+	}
+	return vferr
+}
@@ -305,7 +305,7 @@ func (ctx *Context) Sync(dryrun bool) (err error) {
 		var vcsCmd *VCSCmd
 		repoRootDir := filepath.Join(cacheRoot, repoRoot)
 		if err != nil {
-			rr, err := vcs.RepoRootForImportPath(from, false)
+			rr, err := repoRootForImportPath(from, ctx.Insecure)
 			if err != nil {
 				rem = append(rem, remoteFailure{Msg: "failed to ping remote repo", Path: vp.Path, Err: err})
 				continue
@@ -0,0 +1,97 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// TableOptions controls how FormatTable lays out a status list.
+type TableOptions struct {
+	Version  bool // Add a version column.
+	MaxWidth int  // Maximum width of the path column, zero for no limit.
+	Wrap     bool // Wrap paths longer than MaxWidth instead of truncating them.
+}
+
+// FormatTable writes the status list as a table with a header and columns
+// aligned to the widest value. A path longer than MaxWidth is either
+// wrapped onto following lines or truncated at the front, keeping the
+// end of the path which is often the most telling part.
+func FormatTable(w io.Writer, list []StatusItem, opt TableOptions) error {
+	const truncated = "..."
+	rows := make([][]string, 0, len(list)+1)
+	header := []string{"STATUS", "PATH"}
+	if opt.Version {
+		header = append(header, "VERSION")
+	}
+	rows = append(rows, header)
+	for _, item := range list {
+		version := item.Pkg.Version
+		if len(item.VersionExact) > 0 && item.VersionExact != version {
+			version += " (" + item.VersionExact + ")"
+		}
+		lines := []string{item.Local}
+		if opt.MaxWidth > 0 && len(item.Local) > opt.MaxWidth {
+			switch {
+			case opt.Wrap:
+				lines = lines[:0]
+				for p := item.Local; len(p) > 0; {
+					n := opt.MaxWidth
+					if n > len(p) {
+						n = len(p)
+					}
+					lines = append(lines, p[:n])
+					p = p[n:]
+				}
+			case opt.MaxWidth > len(truncated):
+				lines[0] = truncated + item.Local[len(item.Local)-opt.MaxWidth+len(truncated):]
+			default:
+				lines[0] = item.Local[len(item.Local)-opt.MaxWidth:]
+			}
+		}
+		for i, line := range lines {
+			row := []string{"", line}
+			if i == 0 {
+				row[0] = strings.TrimRight(item.Status.String(), " ")
+			}
+			if opt.Version {
+				if i == 0 {
+					row = append(row, version)
+				} else {
+					row = append(row, "")
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	width := make([]int, len(header))
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > width[i] {
+				width[i] = len(cell)
+			}
+		}
+	}
+	buf := &bytes.Buffer{}
+	for _, row := range rows {
+		line := &bytes.Buffer{}
+		for i, cell := range row {
+			if i > 0 {
+				line.WriteString("  ")
+			}
+			line.WriteString(cell)
+			if i < len(row)-1 {
+				line.WriteString(strings.Repeat(" ", width[i]-len(cell)))
+			}
+		}
+		buf.WriteString(strings.TrimRight(line.String(), " "))
+		buf.WriteByte('\n')
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,69 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kardianos/govendor/pkgspec"
+)
+
+func TestFormatTable(t *testing.T) {
+	list := []StatusItem{
+		{
+			Status:       Status{Type: TypePackage, Location: LocationVendor, Presence: PresenceFound},
+			Pkg:          &pkgspec.Pkg{Path: "github.com/abc/def", Version: "v1"},
+			VersionExact: "v1.2.0",
+			Local:        "co1/vendor/github.com/abc/def",
+		},
+		{
+			Status: Status{Type: TypePackage, Location: LocationLocal, Presence: PresenceFound},
+			Pkg:    &pkgspec.Pkg{Path: "co1/pk1"},
+			Local:  "co1/pk1",
+		},
+	}
+	for _, item := range []struct {
+		name string
+		opt  TableOptions
+		want string
+	}{
+		{
+			name: "plain",
+			opt:  TableOptions{Version: true},
+			want: `STATUS  PATH                           VERSION
+ v      co1/vendor/github.com/abc/def  v1 (v1.2.0)
+ l      co1/pk1
+`,
+		},
+		{
+			name: "truncate",
+			opt:  TableOptions{MaxWidth: 12},
+			want: `STATUS  PATH
+ v      ...m/abc/def
+ l      co1/pk1
+`,
+		},
+		{
+			name: "wrap",
+			opt:  TableOptions{MaxWidth: 12, Wrap: true, Version: true},
+			want: `STATUS  PATH          VERSION
+ v      co1/vendor/g  v1 (v1.2.0)
+        ithub.com/ab
+        c/def
+ l      co1/pk1
+`,
+		},
+	} {
+		buf := &bytes.Buffer{}
+		err := FormatTable(buf, list, item.opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != item.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", item.name, got, item.want)
+		}
+	}
+}
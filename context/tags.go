@@ -6,6 +6,7 @@ package context
 
 import (
 	"bytes"
+	"fmt"
 	"strings"
 )
 
@@ -204,6 +205,29 @@ func (ts *TagSet) IgnoreItem(ignoreList ...string) bool {
 	return ts.root.ignored(ignoreTags)
 }
 
+// IgnoreCopy reports if the file should be left out when copying a package.
+// Unlike IgnoreItem only the tags in the ignore list are used. Files that
+// never build, such as files tagged "ignore" or with conflicting tags, are
+// still copied so the vendored package stays complete.
+func (ts *TagSet) IgnoreCopy(ignoreList ...string) bool {
+	if ts == nil {
+		return false
+	}
+	if ts.ignore {
+		for _, tag := range ignoreList {
+			if tag == "ignore" {
+				return true
+			}
+		}
+	}
+	ts.root.and = true
+	ignoreTags := make([]logicalTag, len(ignoreList))
+	for i := 0; i < len(ignoreList); i++ {
+		ignoreTags[i] = newLogicalTag(ignoreList[i])
+	}
+	return ts.root.ignored(ignoreTags)
+}
+
 func (ts *TagSet) AddFileTag(tag string) {
 	if ts == nil {
 		return
@@ -238,3 +262,151 @@ func (ts *TagSet) AddBuildTags(tags string) {
 
 	}
 }
+
+// AddBuildExpr adds a "//go:build" expression. A file with a "//go:build"
+// line ignores any "// +build" lines, so only one of AddBuildExpr or
+// AddBuildTags should be used for a file.
+func (ts *TagSet) AddBuildExpr(x *BuildExpr) {
+	if ts == nil {
+		return
+	}
+	ts.root.and = true
+	for _, part := range splitAnd(x) {
+		if part.Op == 0 && part.Tag == "ignore" {
+			ts.ignore = true
+			return
+		}
+	}
+	ts.root.sub = append(ts.root.sub, exprLogical(x, false))
+}
+
+// splitAnd returns the top level terms of x that are and'ed together.
+func splitAnd(x *BuildExpr) []*BuildExpr {
+	if x.Op == '&' {
+		return append(splitAnd(x.X), splitAnd(x.Y)...)
+	}
+	return []*BuildExpr{x}
+}
+
+// exprLogical converts a build expression into a logical. Negations are
+// pushed down to the tags so each tag can be matched on its own.
+func exprLogical(x *BuildExpr, not bool) logical {
+	switch x.Op {
+	case 0:
+		return logical{and: true, tag: []logicalTag{{not: not, tag: x.Tag}}}
+	case '!':
+		return exprLogical(x.X, !not)
+	case '&':
+		return logical{and: !not, sub: []logical{exprLogical(x.X, not), exprLogical(x.Y, not)}}
+	case '|':
+		return logical{and: not, sub: []logical{exprLogical(x.X, not), exprLogical(x.Y, not)}}
+	}
+	return logical{and: true}
+}
+
+// BuildExpr is a parsed "//go:build" expression.
+type BuildExpr struct {
+	Op   byte   // Zero for a tag, otherwise '!', '&' or '|'.
+	Tag  string // Set if Op is zero.
+	X, Y *BuildExpr
+}
+
+const goBuildPrefix = "//go:build"
+
+// isGoBuild reports if the comment text is a "//go:build" line.
+func isGoBuild(text string) bool {
+	if !strings.HasPrefix(text, goBuildPrefix) {
+		return false
+	}
+	rest := text[len(goBuildPrefix):]
+	return len(rest) == 0 || rest[0] == ' ' || rest[0] == '\t'
+}
+
+// ParseGoBuild parses a "//go:build" line such as
+// "//go:build linux && (amd64 || arm)".
+func ParseGoBuild(text string) (*BuildExpr, error) {
+	if !isGoBuild(text) {
+		return nil, fmt.Errorf("not a //go:build line: %q", text)
+	}
+	p := &buildParser{s: text[len(goBuildPrefix):]}
+	x := p.or()
+	p.space()
+	if p.err == nil && p.pos < len(p.s) {
+		p.fail()
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return x, nil
+}
+
+// buildParser is a recursive descent parser of "//go:build" expressions.
+// After the first error the returned expressions are not used.
+type buildParser struct {
+	s   string
+	pos int
+	err error
+}
+
+func (p *buildParser) fail() {
+	if p.err == nil {
+		p.err = fmt.Errorf("invalid //go:build expression %q at offset %d", strings.TrimSpace(p.s), p.pos)
+	}
+}
+
+func (p *buildParser) space() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *buildParser) next(op string) bool {
+	p.space()
+	if strings.HasPrefix(p.s[p.pos:], op) {
+		p.pos += len(op)
+		return true
+	}
+	return false
+}
+
+func (p *buildParser) or() *BuildExpr {
+	x := p.and()
+	for p.err == nil && p.next("||") {
+		x = &BuildExpr{Op: '|', X: x, Y: p.and()}
+	}
+	return x
+}
+
+func (p *buildParser) and() *BuildExpr {
+	x := p.not()
+	for p.err == nil && p.next("&&") {
+		x = &BuildExpr{Op: '&', X: x, Y: p.not()}
+	}
+	return x
+}
+
+func (p *buildParser) not() *BuildExpr {
+	if p.next("!") {
+		return &BuildExpr{Op: '!', X: p.not()}
+	}
+	if p.next("(") {
+		x := p.or()
+		if !p.next(")") {
+			p.fail()
+		}
+		return x
+	}
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if c != '_' && c != '.' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9') {
+			break
+		}
+		p.pos++
+	}
+	if start == p.pos {
+		p.fail()
+		return &BuildExpr{}
+	}
+	return &BuildExpr{Tag: p.s[start:p.pos]}
+}
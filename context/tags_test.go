@@ -110,3 +110,78 @@ func TestTagComplex(t *testing.T) {
 		}
 	}
 }
+
+func TestTagGoBuild(t *testing.T) {
+	list := []struct {
+		ignoreList string
+		goBuild    string
+		ignored    bool
+	}{
+		{ignoreList: "", goBuild: "ignore", ignored: true},
+		{ignoreList: "", goBuild: "linux && ignore", ignored: true},
+		{ignoreList: "appengine", goBuild: "appengine", ignored: true},
+		{ignoreList: "appengine", goBuild: "!appengine", ignored: false},
+		{ignoreList: "mips", goBuild: "linux && mips", ignored: true},
+		{ignoreList: "mips", goBuild: "linux || mips", ignored: false},
+		{ignoreList: "linux mips", goBuild: "linux || mips", ignored: true},
+		{ignoreList: "mips", goBuild: "linux && (amd64 || mips)", ignored: false},
+		{ignoreList: "arm mips", goBuild: "linux && (arm || mips)", ignored: true},
+		{ignoreList: "cgo", goBuild: "!(!cgo || windows)", ignored: true},
+	}
+	for index, item := range list {
+		x, err := ParseGoBuild("//go:build " + item.goBuild)
+		if err != nil {
+			t.Fatalf("index %d: %v", index, err)
+		}
+		ts := &TagSet{}
+		ts.AddBuildExpr(x)
+
+		ignored := ts.IgnoreItem(strings.Fields(item.ignoreList)...)
+		if ignored != item.ignored {
+			t.Errorf("index %d wanted ignored=%t, got ignored=%t: ignore=%q build=%q", index, item.ignored, ignored, item.ignoreList, item.goBuild)
+		}
+	}
+}
+
+func TestParseGoBuild(t *testing.T) {
+	list := []struct {
+		line string
+		expr string
+	}{
+		{"//go:build linux", "linux"},
+		{"//go:build !linux && amd64 || arm", "((!linux & amd64) | arm)"},
+		{"//go:build linux && (amd64 || arm)", "(linux & (amd64 | arm))"},
+		{"//go:build\tgo1.8 && !appengine", "(go1.8 & !appengine)"},
+		{"//go:build", ""},
+		{"//go:build linux &&", ""},
+		{"//go:build (linux", ""},
+		{"//go:build linux arm", ""},
+		{"//go:buildlinux", ""},
+	}
+	var format func(x *BuildExpr) string
+	format = func(x *BuildExpr) string {
+		switch x.Op {
+		case 0:
+			return x.Tag
+		case '!':
+			return "!" + format(x.X)
+		}
+		return "(" + format(x.X) + " " + string(x.Op) + " " + format(x.Y) + ")"
+	}
+	for _, item := range list {
+		x, err := ParseGoBuild(item.line)
+		if len(item.expr) == 0 {
+			if err == nil {
+				t.Errorf("%q: expected error, got %s", item.line, format(x))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", item.line, err)
+			continue
+		}
+		if got := format(x); got != item.expr {
+			t.Errorf("%q: got %s, want %s", item.line, got, item.expr)
+		}
+	}
+}
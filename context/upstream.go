@@ -0,0 +1,72 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/kardianos/govendor/pkgspec"
+	"github.com/kardianos/govendor/vendorfile"
+)
+
+// SetUpstream records the canonical upstream of a vendored package that is
+// copied from a fork. The fork stays in the package origin, so later updates
+// still copy from it. An empty upstream removes the record. The vendor file
+// is not written.
+func (ctx *Context) SetUpstream(importPath, upstream string) error {
+	vp := ctx.VendorFilePackagePath(importPath)
+	if vp == nil {
+		return ErrNotVendored{Path: importPath}
+	}
+	vp.Upstream = strings.Trim(upstream, "/")
+	return nil
+}
+
+// Repoint copies a vendored package again from origin, such as when the
+// upstream repository was renamed, and records origin in the vendor file.
+// The package keeps its import path and location in the vendor folder, so
+// no imports change. An empty origin copies from the import path again.
+// The vendor file is written.
+func (ctx *Context) Repoint(importPath, origin string) error {
+	vp := ctx.VendorFilePackagePath(importPath)
+	if vp == nil {
+		return ErrNotVendored{Path: importPath}
+	}
+	origin = strings.Trim(origin, "/")
+	if origin == importPath {
+		origin = ""
+	}
+	err := ctx.ModifyImport(&pkgspec.Pkg{Path: vp.Path, HasOrigin: true, Origin: origin, IncludeTree: vp.Tree}, Update)
+	if err != nil {
+		return err
+	}
+	err = ctx.Alter()
+	vferr := ctx.WriteVendorFile()
+	if err != nil {
+		return err
+	}
+	return vferr
+}
+
+// Forks returns the vendored packages that record an upstream, sorted
+// by import path.
+func (ctx *Context) Forks() []*vendorfile.Package {
+	var list []*vendorfile.Package
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove || len(vp.Upstream) == 0 {
+			continue
+		}
+		list = append(list, vp)
+	}
+	sort.Sort(vendorPackagePathSort(list))
+	return list
+}
+
+type vendorPackagePathSort []*vendorfile.Package
+
+func (l vendorPackagePathSort) Len() int           { return len(l) }
+func (l vendorPackagePathSort) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+func (l vendorPackagePathSort) Less(i, j int) bool { return l[i].Path < l[j].Path }
@@ -16,6 +16,42 @@ import (
 	os "github.com/kardianos/govendor/internal/vos"
 )
 
+// VendorFileValidator checks a vendor file after it is read.
+type VendorFileValidator func(vf *vendorfile.File) error
+
+var vendorFileValidators []VendorFileValidator
+
+// RegisterVendorFileValidator adds a validator that is run each time
+// a context reads an existing vendor file. If a validator returns an
+// error, creating the context fails with that error. Not safe to call
+// concurrently with creating a context.
+func RegisterVendorFileValidator(v VendorFileValidator) {
+	vendorFileValidators = append(vendorFileValidators, v)
+}
+
+// SetVendorFileName reads and writes the vendor file under the given file
+// name, in the same folder, instead of "vendor.json". This allows working
+// with manifests written by other tools. The vendor file is read again
+// from the new location; a missing file starts an empty vendor file.
+func (ctx *Context) SetVendorFileName(name string) error {
+	if len(name) == 0 || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return ErrVendorFileName{Name: name}
+	}
+	dir, _ := filepath.Split(ctx.VendorFilePath)
+	vendorFilePath := filepath.Join(dir, name)
+	vf, err := readVendorFile(ctx.InternalPrefix()+"/", vendorFilePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		vf = &vendorfile.File{}
+	}
+	ctx.VendorFilePath = vendorFilePath
+	ctx.VendorFile = vf
+	ctx.IgnoreBuildAndPackage(vf.Ignore)
+	return nil
+}
+
 // WriteVendorFile writes the current vendor file to the context location.
 func (ctx *Context) WriteVendorFile() (err error) {
 	perm := ros.FileMode(0666)
@@ -25,12 +61,22 @@ func (ctx *Context) WriteVendorFile() (err error) {
 	}
 
 	ctx.VendorFile.RootPath = ctx.RootImportPath
+	err = ctx.VendorFile.Normalize()
+	if err != nil {
+		return
+	}
 
 	buf := &bytes.Buffer{}
 	err = ctx.VendorFile.Marshal(buf)
 	if err != nil {
 		return
 	}
+	if ctx.VendorFile.CRLF {
+		err = buf.WriteByte('\r')
+		if err != nil {
+			return
+		}
+	}
 	err = buf.WriteByte('\n')
 	if err != nil {
 		return
@@ -66,6 +112,9 @@ func readVendorFile(vendorRoot, vendorFilePath string) (*vendorfile.File, error)
 
 	err = vf.Unmarshal(f)
 	if err != nil {
+		if encErr, is := err.(vendorfile.EncodingError); is {
+			return nil, ErrVendorFileEncoding{Path: vendorFilePath, Offset: encErr.Offset}
+		}
 		return nil, err
 	}
 	// Remove any existing origin field if the prefix matches the
@@ -75,5 +124,12 @@ func readVendorFile(vendorRoot, vendorFilePath string) (*vendorfile.File, error)
 		row.Origin = strings.TrimPrefix(row.Origin, vendorRoot)
 	}
 
+	for _, v := range vendorFileValidators {
+		err = v(vf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return vf, nil
 }
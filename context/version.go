@@ -6,9 +6,35 @@ package context
 
 import (
 	"strconv"
+	"strings"
+	"time"
 	"unicode"
 )
 
+// VersionOf returns the version and revision time recorded in the vendor
+// file for a vendored package. The import path may be the path in the vendor
+// file or the path in the vendor folder. If no version was recorded the
+// revision is returned. Sub-packages of a tree package return the version
+// of the tree package. The time is zero if it was not recorded.
+func (ctx *Context) VersionOf(importPath string) (version string, t time.Time, ok bool) {
+	importPath = strings.TrimPrefix(importPath, ctx.InternalPrefix()+"/")
+	vp := ctx.VendorFilePackagePath(importPath)
+	if vp == nil {
+		if parent := ctx.VendorParent(importPath); parent != nil && parent.Tree {
+			vp = parent
+		}
+	}
+	if vp == nil {
+		return "", time.Time{}, false
+	}
+	version = vp.Version
+	if len(version) == 0 {
+		version = vp.Revision
+	}
+	t, _ = time.Parse(time.RFC3339, vp.RevisionTime)
+	return version, t, true
+}
+
 // IsVersion returns true if the string is a version.
 func isVersion(s string) bool {
 	hasPunct := false
@@ -0,0 +1,118 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+)
+
+// WarningCode identifies the kind of a Warning.
+type WarningCode string
+
+const (
+	// WarnNoVersion is given when version information for a package
+	// could not be determined.
+	WarnNoVersion WarningCode = "no-version"
+
+	// WarnRelativeRead is given when a package reads a file using
+	// a relative path.
+	WarnRelativeRead WarningCode = "relative-read"
+
+	// WarnLayoutNested is given when a package would be placed inside
+	// a vendored tree package.
+	WarnLayoutNested WarningCode = "layout-nested"
+
+	// WarnLayoutDuplicate is given when the same path would be vendored
+	// from two different origins.
+	WarnLayoutDuplicate WarningCode = "layout-duplicate"
+
+	// WarnLayoutCase is given when two paths only differ by case.
+	WarnLayoutCase WarningCode = "layout-case"
+
+	// WarnStrayVendorImport is given when project code imports a path
+	// in the vendor folder that is not a vendored package.
+	WarnStrayVendorImport WarningCode = "stray-vendor-import"
+
+	// WarnInternalImport is given when a package imports an internal
+	// package it is not allowed to import.
+	WarnInternalImport WarningCode = "internal-import"
+
+	// WarnRemovedMissing is given when a removed package is still
+	// imported but is not found in GOPATH.
+	WarnRemovedMissing WarningCode = "removed-missing"
+
+	// WarnUntrackedVendor is given when a package is in the vendor
+	// folder but not in the vendor file.
+	WarnUntrackedVendor WarningCode = "untracked-vendor"
+
+	// WarnParentHeader is given when a package includes a C header
+	// from outside of the package folder.
+	WarnParentHeader WarningCode = "parent-header"
+
+	// WarnMixedImport is given when a dependency is imported by both
+	// its original and its vendored path.
+	WarnMixedImport WarningCode = "mixed-import"
+
+	// WarnRevisionMismatch is given when the recorded revision of a
+	// package differs from the revision of its source.
+	WarnRevisionMismatch WarningCode = "revision-mismatch"
+
+	// WarnVendorImportsProject is given when a vendored package imports
+	// a package of the project.
+	WarnVendorImportsProject WarningCode = "vendor-imports-project"
+
+	// WarnGopathShadow is given when a vendored package is also
+	// imported from a GOPATH copy.
+	WarnGopathShadow WarningCode = "gopath-shadow"
+
+	// WarnFileTooLarge is given when a file is not copied because it
+	// is larger than the maximum file size.
+	WarnFileTooLarge WarningCode = "file-too-large"
+)
+
+// Warning is a non-fatal problem found while modifying the project.
+type Warning struct {
+	Code    WarningCode
+	Message string
+	Path    string // Import path or file path the warning relates to.
+}
+
+func (w Warning) String() string {
+	if len(w.Path) == 0 {
+		return fmt.Sprintf("warning: %s", w.Message)
+	}
+	return fmt.Sprintf("warning: %q: %s", w.Path, w.Message)
+}
+
+// warn records a warning to be returned to the caller.
+func (ctx *Context) warn(code WarningCode, path string, format string, v ...interface{}) {
+	w := Warning{
+		Code:    code,
+		Message: fmt.Sprintf(format, v...),
+		Path:    path,
+	}
+	ctx.Warning = append(ctx.Warning, w)
+}
+
+// AlterWarn runs Alter and returns any warnings collected since the
+// last call to AlterWarn, including those from queuing modifications.
+func (ctx *Context) AlterWarn() ([]Warning, error) {
+	err := ctx.Alter()
+	warning := ctx.Warning
+	ctx.Warning = nil
+	return warning, err
+}
+
+// warningSort orders warnings by path, then message.
+type warningSort []Warning
+
+func (l warningSort) Len() int      { return len(l) }
+func (l warningSort) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l warningSort) Less(i, j int) bool {
+	if l[i].Path != l[j].Path {
+		return l[i].Path < l[j].Path
+	}
+	return l[i].Message < l[j].Message
+}
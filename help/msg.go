@@ -17,6 +17,9 @@ const (
 	MsgGet
 	MsgLicense
 	MsgShell
+	MsgExport
+	MsgImport
+	MsgInfo
 	MsgGovendorLicense
 	MsgGovendorVersion
 )
@@ -53,6 +56,12 @@ func (msg HelpMessage) String() string {
 		msgText = helpLicense
 	case MsgShell:
 		msgText = helpShell
+	case MsgExport:
+		msgText = helpExport
+	case MsgImport:
+		msgText = helpImport
+	case MsgInfo:
+		msgText = helpInfo
 	case MsgGovendorLicense:
 		msgText = msgGovendorLicenses
 	case MsgGovendorVersion:
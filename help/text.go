@@ -5,8 +5,11 @@
 package help
 
 import (
+	"bytes"
+	"fmt"
 	"strings"
 
+	"github.com/kardianos/govendor/context"
 	"github.com/kardianos/govendor/migrate"
 )
 
@@ -34,24 +37,16 @@ Sub-Commands
 	license  List discovered licenses for the given status or import paths.
 	shell    Run a "shell" to make multiple sub-commands more efficient for large
 	             projects.
+	export   Write the vendor folder and vendor file to a tar archive.
+	import   Unpack an archive from "export" into the vendor folder.
+	info     Show the project root, GOPATH, GOROOT and vendor folder in use.
 
 	go tool commands that are wrapped:
 	  "+status" package selection may be used with them
 	fmt, build, install, clean, test, vet, generate, tool
 
 Status Types
-
-	+local    (l) packages in your project
-	+external (e) referenced packages in GOPATH but not in current project
-	+vendor   (v) packages in the vendor folder
-	+std      (s) packages in the standard library
-
-	+excluded (x) external packages explicitly excluded from vendoring
-	+unused   (u) packages in the vendor folder, but unused
-	+missing  (m) referenced packages but not found
-
-	+program  (p) package is a main package
-
+` + statusTypes() + `
 	+outside  +external +missing
 	+all      +all packages
 
@@ -74,17 +69,45 @@ If using go1.5, ensure GO15VENDOREXPERIMENT=1 is set.
 
 `
 
+// statusTypes lists each status, with a blank line between groups.
+func statusTypes() string {
+	buf := &bytes.Buffer{}
+	var last context.Status
+	for i, info := range context.AllStatuses() {
+		st := info.Status
+		if i == 0 || (st.Location == context.LocationUnknown) != (last.Location == context.LocationUnknown) ||
+			(st.Presence == context.PresenceUnknown) != (last.Presence == context.PresenceUnknown) {
+			buf.WriteString("\n")
+		}
+		last = st
+		fmt.Fprintf(buf, "\t+%-8s (%c) %s\n", info.Name, info.Letter, info.Description)
+	}
+	return buf.String()
+}
+
 var helpInit = `govendor init
 	Create a vendor folder in the working directory and a vendor/vendor.json
-	metadata file.
+	metadata file. An existing vendor file keeps its settings, an empty one
+	is initialized.
 `
 
 var helpList = `govendor list [options]  ( +status or import-path-filter )
 	List all dependencies and packages in folder tree.
 	Options:
-		-v           verbose listing, show dependencies of each package
+		-v           verbose listing, show dependencies of each package and
+		             the files that import a missing package
 		-p           show file path to package instead of import path
 		-no-status   do not prefix status to list, package names only
+		-d           only look for packages in the given directory, imports
+		             are still resolved against the whole project
+		-table       show an aligned table of status, path and version
+		-width       maximum width of the path column in a table
+		-wrap        wrap paths longer than the width instead of truncating
+		-gopaths     show every GOPATH location of external packages found
+		             in more than one GOPATH, the first one is copied by add
+		-file-order  list vendor packages in the order of the vendor file
+		-dot         write the import graph of all packages except the standard
+		             library in Graphviz DOT format, filters are not used
 Examples:
 	$ govendor list -no-status +local
 	$ govendor list -p -no-status +local
@@ -99,6 +122,13 @@ var helpAdd = `govendor add [options] ( +status or import-path-filter )
 		-tree        copy package(s) and all sub-folders under each package
 		-uncommitted allows copying a package with uncommitted changes, doesn't
 		             update revision or checksum so it will always be out-of-date.
+		-exclude     comma separated globs of directories, relative to the
+		             package, to skip when copying a tree; kept in the vendor file
+		-test        run "go test" on the copied packages in the vendor folder,
+		             without network access
+		-max-size    do not copy non-Go files larger than this many bytes,
+		             a warning is printed for each skipped file, the limit
+		             is saved in the vendor file
 
 		The following may be replaced with something else in the future.
 		-short       if conflict, take short path
@@ -112,6 +142,11 @@ var helpUpdate = `govendor update [options] ( +status or import-path-filter )
 		-tree        copy package(s) and all sub-folders under each package
 		-uncommitted allows copying a package with uncommitted changes, doesn't
 		             update revision or checksum so it will always be out-of-date.
+		-history     keep this many previous revisions of each package in
+		             the vendor file
+		-max-size    do not copy non-Go files larger than this many bytes,
+		             a warning is printed for each skipped file, the limit
+		             is saved in the vendor file
 
 		The following may be replaced with something else in the future.
 		-short       if conflict, take short path
@@ -131,6 +166,8 @@ var helpFetch = `govendor fetch [options] ( +status or package-spec )
 		-tree        copy package(s) and all sub-folders under each package
 		-insecure    allow downloading over insecure connection
 		-v           verbose mode
+		-history     keep this many previous revisions of each package in
+		             the vendor file
 `
 
 var helpSync = `govendor sync
@@ -162,6 +199,8 @@ var helpLicense = `govendor license [options] ( +status or package-spec )
 	Options:
 		-o           output to file name
 		-template    template file to use, input is "[]context.License"
+		-missing     list vendored packages without a license file, fails
+		             if there are any
 `
 var helpShell = `govendor shell
 	Open a govendor "shell". Useful for faster queries on large projects.
@@ -169,5 +208,25 @@ var helpShell = `govendor shell
 		-pprof-handler    expose a pprof HTTP server on the given address
 `
 
+var helpExport = `govendor export [options]
+	Write the vendor folder and vendor file to a tar archive. The archive is
+	reproducible: entries are sorted and have fixed times and owners.
+	Options:
+		-o           output to file name, defaults to standard output
+		-gz          gzip compress the archive
+`
+
+var helpImport = `govendor import [archive-file]
+	Unpack an archive written by "govendor export" into the vendor folder and
+	merge its vendor file. Reads standard input if no file is given. The
+	archive may be gzip compressed.
+`
+
+var helpInfo = `govendor info
+	Show how the project was found: the root folder and import path, the
+	GOPATH and GOROOT used, the vendor folder and file, and the number of
+	packages in the vendor file. Useful to diagnose unexpected statuses.
+`
+
 var msgGovendorVersion = version + `
 `
@@ -61,6 +61,16 @@ func (h *HttpHandler) HttpAddr() string {
 	return h.httpAddr
 }
 
+// VcsAddr returns the address of the version control server, without a
+// scheme, that serves repositories without an HTTP meta lookup.
+func (h *HttpHandler) VcsAddr() string {
+	addr := h.vcsAddr
+	if i := strings.Index(addr, "://"); i >= 0 {
+		addr = addr[i+3:]
+	}
+	return strings.TrimSuffix(addr, "/")
+}
+
 // Setup returns type with Remove function that can be defer'ed.
 func (h *HttpHandler) Setup() VcsHandle {
 	vcs := h.newer(h)
@@ -110,6 +110,16 @@ func FileStringEquals(s1, s2 string) bool {
 	return caseInsensitiveEq(s1, s2)
 }
 
+// FileKey returns a key for the file path, so two paths that refer to the
+// same file by FileStringEquals, after cleaning, have the same key.
+func FileKey(path string) string {
+	path = filepath.Clean(path)
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return strings.ToLower(path)
+	}
+	return path
+}
+
 func caseInsensitiveEq(s1, s2 string) bool {
 	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
 		return strings.EqualFold(s1, s2)
@@ -117,6 +127,32 @@ func caseInsensitiveEq(s1, s2 string) bool {
 	return s1 == s2
 }
 
+// LongPath returns an extended-length path on windows, so paths longer
+// than MAX_PATH (260 characters) may be used. On other platforms or
+// for relative paths the path is returned unchanged.
+func LongPath(path string) string {
+	if runtime.GOOS != "windows" {
+		return path
+	}
+	return longPath(path)
+}
+
+func longPath(path string) string {
+	const prefix = `\\?\`
+	if strings.HasPrefix(path, prefix) {
+		return path
+	}
+	path = strings.Replace(path, "/", `\`, -1)
+	switch {
+	case strings.HasPrefix(path, `\\`):
+		// UNC path: \\server\share -> \\?\UNC\server\share
+		return prefix + `UNC` + path[1:]
+	case len(path) >= 3 && path[1] == ':' && path[2] == '\\':
+		return prefix + path
+	}
+	return path
+}
+
 // ParseGoEnvLine parses a "go env" line into a key value pair.
 func ParseGoEnvLine(line string) (key, value string, ok bool) {
 	// Remove any leading "set " found on windows.
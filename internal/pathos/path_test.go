@@ -59,3 +59,29 @@ func TestGoEnv(t *testing.T) {
 		}
 	}
 }
+
+func TestLongPath(t *testing.T) {
+	list := []struct {
+		path, result string
+	}{
+		{`C:\go\src\a`, `\\?\C:\go\src\a`},
+		{`C:/go/src/a`, `\\?\C:\go\src\a`},
+		{`\\server\share\a`, `\\?\UNC\server\share\a`},
+		{`\\?\C:\go\src\a`, `\\?\C:\go\src\a`},
+		{`go\src\a`, `go\src\a`},
+	}
+	for _, item := range list {
+		if got := longPath(item.path); got != item.result {
+			t.Errorf("for %q got %q, want %q", item.path, got, item.result)
+		}
+	}
+}
+
+func TestFileKey(t *testing.T) {
+	if FileKey("/a/b/../c/d.go") != FileKey("/a/c/./d.go") {
+		t.Fatal("cleaned paths have different keys")
+	}
+	if FileKey("/a/c/d.go") == FileKey("/a/c/e.go") {
+		t.Fatal("different files have the same key")
+	}
+}
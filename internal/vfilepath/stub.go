@@ -4,6 +4,8 @@ import (
 	"path/filepath"
 )
 
+const Separator = filepath.Separator
+
 func Split(path string) (string, string) {
 	return filepath.Split(path)
 }
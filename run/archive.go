@@ -0,0 +1,67 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package run
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/kardianos/govendor/context"
+	"github.com/kardianos/govendor/help"
+)
+
+func (r *runner) Export(w io.Writer, subCmdArgs []string) (help.HelpMessage, error) {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(nullWriter{})
+	outputFilename := flags.String("o", "", "output")
+	gz := flags.Bool("gz", false, "gzip")
+	err := flags.Parse(subCmdArgs)
+	if err != nil {
+		return help.MsgExport, err
+	}
+	ctx, err := r.NewContextWD(context.RootVendor)
+	if err != nil {
+		return checkNewContextError(err)
+	}
+	if len(*outputFilename) == 0 {
+		return help.MsgNone, ctx.Export(w, *gz)
+	}
+	f, err := os.Create(*outputFilename)
+	if err != nil {
+		return help.MsgNone, err
+	}
+	err = ctx.Export(f, *gz)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return help.MsgNone, err
+}
+
+func (r *runner) Import(w io.Writer, subCmdArgs []string) (help.HelpMessage, error) {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(nullWriter{})
+	err := flags.Parse(subCmdArgs)
+	if err != nil {
+		return help.MsgImport, err
+	}
+	if flags.NArg() > 1 {
+		return help.MsgImport, errors.New("too many arguments")
+	}
+	ctx, err := r.NewContextWD(context.RootVendor)
+	if err != nil {
+		return checkNewContextError(err)
+	}
+	if flags.NArg() == 0 {
+		return help.MsgNone, ctx.Import(os.Stdin)
+	}
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return help.MsgNone, err
+	}
+	defer f.Close()
+	return help.MsgNone, ctx.Import(f)
+}
@@ -28,7 +28,10 @@ func (r *runner) Init(w io.Writer, subCmdArgs []string) (help.HelpMessage, error
 	if err != nil {
 		return help.MsgNone, err
 	}
-	ctx.VendorFile.Ignore = "test" // Add default ignore rule.
+	// Keep the settings of an initialized vendor file.
+	if ctx.VendorFile.Empty() {
+		ctx.VendorFile.Ignore = "test" // Add default ignore rule.
+	}
 	err = ctx.WriteVendorFile()
 	if err != nil {
 		return help.MsgNone, err
@@ -156,7 +159,8 @@ func (r *runner) Status(w io.Writer, subCmdArgs []string) (help.HelpMessage, err
 	if err != nil {
 		return help.MsgStatus, err
 	}
-	ctx, err := r.NewContextWD(context.RootVendor)
+	// Read only, an uninitialized project has nothing out of date.
+	ctx, err := r.NewContextWD(context.RootVendorOrWD)
 	if err != nil {
 		return help.MsgStatus, err
 	}
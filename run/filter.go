@@ -47,6 +47,45 @@ func statusGroupFromList(list []context.Status, and, not bool) context.StatusGro
 
 const notOp = "^"
 
+// statusAlias maps other accepted status names to a name in
+// context.AllStatuses.
+var statusAlias = map[string]string{
+	"standard": "std",
+	"xcluded":  "excluded",
+}
+
+// findStatus returns the status with the letter s, or whose name or alias
+// starts with s.
+func findStatus(s string) (context.Status, bool) {
+	if len(s) == 0 {
+		return context.Status{}, false
+	}
+	infos := context.AllStatuses()
+	if len(s) == 1 {
+		for _, info := range infos {
+			if string(info.Letter) == s {
+				return info.Status, true
+			}
+		}
+	}
+	for _, info := range infos {
+		if !strings.HasPrefix(info.Name, s) {
+			continue
+		}
+		// len >= 3 to distinguish from "external".
+		if info.Name == "excluded" && len(s) < 3 {
+			continue
+		}
+		return info.Status, true
+	}
+	for alias, name := range statusAlias {
+		if strings.HasPrefix(alias, s) {
+			return findStatus(name)
+		}
+	}
+	return context.Status{}, false
+}
+
 func parseStatusGroup(statusString string) (sg context.StatusGroup, err error) {
 	ss := strings.Split(statusString, ",")
 	sg.And = true
@@ -58,26 +97,6 @@ func parseStatusGroup(statusString string) (sg context.StatusGroup, err error) {
 		}
 		var list []context.Status
 		switch {
-		case strings.HasPrefix("external", s):
-			st.Location = context.LocationExternal
-		case strings.HasPrefix("vendor", s):
-			st.Location = context.LocationVendor
-		case strings.HasPrefix("unused", s):
-			st.Presence = context.PresenceUnused
-		case strings.HasPrefix("missing", s):
-			st.Presence = context.PresenceMissing
-		case strings.HasPrefix("xcluded", s):
-			st.Presence = context.PresenceExcluded
-		case len(s) >= 3 && strings.HasPrefix("excluded", s): // len >= 3 to distinguish from "external"
-			st.Presence = context.PresenceExcluded
-		case strings.HasPrefix("local", s):
-			st.Location = context.LocationLocal
-		case strings.HasPrefix("program", s):
-			st.Type = context.TypeProgram
-		case strings.HasPrefix("std", s):
-			st.Location = context.LocationStandard
-		case strings.HasPrefix("standard", s):
-			st.Location = context.LocationStandard
 		case strings.HasPrefix("all", s):
 			list = all
 		case strings.HasPrefix("normal", s):
@@ -85,8 +104,12 @@ func parseStatusGroup(statusString string) (sg context.StatusGroup, err error) {
 		case strings.HasPrefix("outside", s):
 			list = outside
 		default:
-			err = fmt.Errorf("unknown status %q", s)
-			return
+			status, found := findStatus(s)
+			if !found {
+				err = fmt.Errorf("unknown status %q", s)
+				return
+			}
+			st.Location, st.Presence, st.Type = status.Location, status.Presence, status.Type
 		}
 		if len(list) == 0 {
 			sg.Status = append(sg.Status, st)
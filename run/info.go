@@ -0,0 +1,38 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package run
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/kardianos/govendor/context"
+	"github.com/kardianos/govendor/help"
+)
+
+func (r *runner) Info(w io.Writer, subCmdArgs []string) (help.HelpMessage, error) {
+	flags := flag.NewFlagSet("info", flag.ContinueOnError)
+	flags.SetOutput(nullWriter{})
+	err := flags.Parse(subCmdArgs)
+	if err != nil {
+		return help.MsgInfo, err
+	}
+	ctx, err := r.NewContextWD(context.RootVendor)
+	if err != nil {
+		return checkNewContextError(err)
+	}
+	info := ctx.Info()
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "root\t%s\n", info.RootDir)
+	fmt.Fprintf(tw, "import path\t%s\n", info.RootImportPath)
+	fmt.Fprintf(tw, "gopath\t%s\n", info.Gopath)
+	fmt.Fprintf(tw, "goroot\t%s\n", info.Goroot)
+	fmt.Fprintf(tw, "vendor folder\t%s\n", info.VendorFolder)
+	fmt.Fprintf(tw, "vendor file\t%s\n", info.VendorFile)
+	fmt.Fprintf(tw, "packages\t%d\n", info.VendorPackages)
+	return help.MsgNone, tw.Flush()
+}
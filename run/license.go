@@ -28,12 +28,31 @@ func (r *runner) License(w io.Writer, subCmdArgs []string) (help.HelpMessage, er
 	flags.SetOutput(nullWriter{})
 	outputFilename := flags.String("o", "", "output")
 	templateFilename := flags.String("template", "", "custom template file")
+	missing := flags.Bool("missing", false, "list vendored packages without a license")
 	err := flags.Parse(subCmdArgs)
 	if err != nil {
 		return help.MsgLicense, err
 	}
 	args := flags.Args()
 
+	if *missing {
+		ctx, err := r.NewContextWD(context.RootVendor)
+		if err != nil {
+			return checkNewContextError(err)
+		}
+		list, err := ctx.PackagesMissingLicense()
+		if err != nil {
+			return help.MsgNone, err
+		}
+		for _, p := range list {
+			fmt.Fprintln(w, p)
+		}
+		if len(list) != 0 {
+			return help.MsgNone, fmt.Errorf("no license file for %d vendored package(s)", len(list))
+		}
+		return help.MsgNone, nil
+	}
+
 	templateText := defaultLicenseTemplate
 	if len(*templateFilename) > 0 {
 		text, err := ioutil.ReadFile(*templateFilename)
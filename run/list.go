@@ -8,6 +8,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"path/filepath"
 	"strings"
 	"text/tabwriter"
 
@@ -21,6 +22,13 @@ func (r *runner) List(w io.Writer, subCmdArgs []string) (help.HelpMessage, error
 	verbose := listFlags.Bool("v", false, "verbose")
 	asFilePath := listFlags.Bool("p", false, "show file path to package instead of import path")
 	noStatus := listFlags.Bool("no-status", false, "do not show the status")
+	scope := listFlags.String("d", "", "only list packages in this directory")
+	table := listFlags.Bool("table", false, "show an aligned table with versions")
+	width := listFlags.Int("width", 0, "maximum width of the path column in a table")
+	wrap := listFlags.Bool("wrap", false, "wrap long paths in a table instead of truncating them")
+	gopaths := listFlags.Bool("gopaths", false, "show each GOPATH location of packages found in more than one")
+	fileOrder := listFlags.Bool("file-order", false, "list vendor packages in vendor file order")
+	dot := listFlags.Bool("dot", false, "write the import graph in Graphviz DOT format")
 	err := listFlags.Parse(subCmdArgs)
 	if err != nil {
 		return help.MsgList, err
@@ -33,6 +41,19 @@ func (r *runner) List(w io.Writer, subCmdArgs []string) (help.HelpMessage, error
 	if err != nil {
 		return checkNewContextError(err)
 	}
+	if len(*scope) > 0 {
+		dir, err := filepath.Abs(*scope)
+		if err != nil {
+			return help.MsgNone, err
+		}
+		err = ctx.Scope(dir)
+		if err != nil {
+			return help.MsgNone, err
+		}
+	}
+	if *dot {
+		return help.MsgNone, ctx.WriteDot(w, false)
+	}
 	cgp, err := currentGoPath(ctx)
 	if err != nil {
 		return help.MsgNone, err
@@ -84,6 +105,36 @@ func (r *runner) List(w io.Writer, subCmdArgs []string) (help.HelpMessage, error
 		list = next
 	}
 
+	if *fileOrder {
+		list = ctx.VendorFileOrder(list)
+	}
+
+	if *table {
+		rows := make([]context.StatusItem, 0, len(list))
+		for _, item := range list {
+			if !f.HasStatus(item) {
+				continue
+			}
+			if len(f.Import) != 0 && f.FindImport(item) == nil {
+				continue
+			}
+			rows = append(rows, item)
+		}
+		return help.MsgNone, context.FormatTable(w, rows, context.TableOptions{
+			Version:  true,
+			MaxWidth: *width,
+			Wrap:     *wrap,
+		})
+	}
+
+	var ambiguous map[string][]string
+	if *gopaths {
+		ambiguous, err = ctx.AmbiguousImports()
+		if err != nil {
+			return help.MsgNone, err
+		}
+	}
+
 	formatSame := "%[1]v %[2]s\t%[3]s\t%[4]s\n"
 	formatDifferent := "%[1]v %[2]s\t%[4]s\t%[5]s\n"
 	if *verbose {
@@ -118,6 +169,11 @@ func (r *runner) List(w io.Writer, subCmdArgs []string) (help.HelpMessage, error
 		} else {
 			fmt.Fprintf(tw, formatDifferent, item.Status, path, strings.TrimPrefix(item.Local, ctx.RootImportPath), item.Pkg.Version, item.VersionExact)
 		}
+		if item.Status.Location == context.LocationExternal {
+			for _, dir := range ambiguous[item.Pkg.Path] {
+				fmt.Fprintf(tw, "    ! found in %s\n", dir)
+			}
+		}
 		if *verbose {
 			for i, imp := range item.ImportedBy {
 				if i != len(item.ImportedBy)-1 {
@@ -126,6 +182,9 @@ func (r *runner) List(w io.Writer, subCmdArgs []string) (help.HelpMessage, error
 					fmt.Fprintf(tw, "    └── %s %s\n", imp.Status, imp)
 				}
 			}
+			for _, fp := range item.ImportedByFile {
+				fmt.Fprintf(tw, "    ! imported in %s\n", fp)
+			}
 		}
 	}
 	return help.MsgNone, nil
@@ -9,6 +9,10 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
 
 	"github.com/kardianos/govendor/context"
 	"github.com/kardianos/govendor/help"
@@ -64,6 +68,10 @@ func (r *runner) Modify(w io.Writer, subCmdArgs []string, mod context.Modify, as
 	tree := listFlags.Bool("tree", false, "copy all folders including and under selected folder")
 	insecure := listFlags.Bool("insecure", false, "allow insecure network updates")
 	uncommitted := listFlags.Bool("uncommitted", false, "allows adding uncommitted changes. Doesn't update revision or checksum")
+	history := listFlags.Int("history", 0, "keep this many previous revisions of each package")
+	exclude := listFlags.String("exclude", "", "comma separated directory globs to skip in tree packages")
+	test := listFlags.Bool("test", false, "run go test on the copied packages")
+	maxSize := listFlags.Int64("max-size", 0, "do not copy non-Go files larger than this many bytes")
 	err = listFlags.Parse(subCmdArgs)
 	if err != nil {
 		return msg, err
@@ -81,8 +89,14 @@ func (r *runner) Modify(w io.Writer, subCmdArgs []string, mod context.Modify, as
 	}
 	if *verbose {
 		ctx.Logger = w
+		ctx.Verbosity = 1
 	}
 	ctx.Insecure = *insecure
+	ctx.HistoryLimit = *history
+	ctx.MaxFileSize = *maxSize
+	if len(*exclude) > 0 {
+		ctx.ExcludeDir = strings.Split(*exclude, ",")
+	}
 	cgp, err := currentGoPath(ctx)
 	if err != nil {
 		return msg, err
@@ -149,7 +163,10 @@ func (r *runner) Modify(w io.Writer, subCmdArgs []string, mod context.Modify, as
 		return help.MsgNone, err
 	}
 	// Write out vendor file and do change.
-	err = ctx.Alter()
+	warning, err := ctx.AlterWarn()
+	for _, warn := range warning {
+		fmt.Fprintln(w, warn)
+	}
 	vferr := ctx.WriteVendorFile()
 	if err != nil {
 		return help.MsgNone, err
@@ -157,5 +174,43 @@ func (r *runner) Modify(w io.Writer, subCmdArgs []string, mod context.Modify, as
 	if vferr != nil {
 		return help.MsgNone, vferr
 	}
+	if *test {
+		return help.MsgNone, testCopied(w, ctx)
+	}
 	return help.MsgNone, nil
 }
+
+// testCopied runs "go test" on the packages copied into the vendor folder,
+// so rewrite mistakes and missing dependencies are found right away.
+// The go tool is not allowed to download anything.
+func testCopied(w io.Writer, ctx *context.Context) error {
+	var local []string
+	for _, op := range ctx.Operation {
+		if op.Type != context.OpCopy {
+			continue
+		}
+		p := path.Join(ctx.InternalPrefix(), op.Pkg.Path)
+		if op.Pkg.IncludeTree {
+			p += "/..."
+		}
+		local = append(local, p)
+	}
+	if len(local) == 0 {
+		return nil
+	}
+	cmd := exec.Command("go", append([]string{"test"}, local...)...)
+	cmd.Dir = ctx.RootDir
+	// The project is in GOPATH, so module mode is turned off.
+	cmd.Env = []string{"GO111MODULE=off", "GOPROXY=off"}
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, "GO111MODULE=") && !strings.HasPrefix(env, "GOPROXY=") {
+			cmd.Env = append(cmd.Env, env)
+		}
+	}
+	cmd.Stdout = w
+	cmd.Stderr = w
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("tests failed for %d copied package(s): %v", len(local), err)
+	}
+	return nil
+}
@@ -106,6 +106,12 @@ func (r *runner) run(w io.Writer, appArgs []string, ask prompt.Prompt) (help.Hel
 		return r.Get(w, args[1:])
 	case "license":
 		return r.License(w, args[1:])
+	case "export":
+		return r.Export(w, args[1:])
+	case "import":
+		return r.Import(w, args[1:])
+	case "info":
+		return r.Info(w, args[1:])
 	case "shell":
 		return r.Shell(w, args[1:])
 	case "fmt", "build", "install", "clean", "test", "vet", "generate", "tool":
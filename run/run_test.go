@@ -8,10 +8,12 @@ import (
 	"bytes"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/kardianos/govendor/context"
 	"github.com/kardianos/govendor/help"
 	"github.com/kardianos/govendor/internal/gt"
 	"github.com/kardianos/govendor/prompt"
@@ -237,3 +239,145 @@ func TestTreeRootEmpty(t *testing.T) {
  l  co1/pk1
 	`)
 }
+
+func TestParseAllStatuses(t *testing.T) {
+	for _, info := range context.AllStatuses() {
+		for _, name := range []string{info.Name, string(info.Letter)} {
+			sg, err := parseStatusGroup(name)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if len(sg.Status) != 1 || sg.Status[0] != info.Status {
+				t.Fatalf("%s: got %v, want %v", name, sg, info.Status)
+			}
+		}
+	}
+	for name, want := range map[string]context.Status{
+		"ex":       {Location: context.LocationExternal},
+		"exc":      {Presence: context.PresenceExcluded},
+		"xc":       {Presence: context.PresenceExcluded},
+		"standard": {Location: context.LocationStandard},
+	} {
+		sg, err := parseStatusGroup(name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(sg.Status) != 1 || sg.Status[0] != want {
+			t.Fatalf("%s: got %v, want %v", name, sg, want)
+		}
+	}
+	if _, err := parseStatusGroup("bogus"); err == nil {
+		t.Fatal("unknown status parsed")
+	}
+}
+
+func TestAddTest(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co2/pk2"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co2/pk2",
+		gt.File("a.go", "strings"),
+	)
+	testFile := func(pkg, body string) {
+		name := path.Base(pkg)
+		g.Check(ioutil.WriteFile(g.Path(pkg+"/a.go"), []byte("package "+name+"\n"), 0666))
+		src := "package " + name + "\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) {\n\t" + body + "\n}\n"
+		g.Check(ioutil.WriteFile(g.Path(pkg+"/a_test.go"), []byte(src), 0666))
+	}
+	testFile("co2/pk1", "")
+	testFile("co2/pk2", `t.Fatal("failed")`)
+	g.In("co1")
+	// The go tool must not use module mode for the GOPATH project.
+	prev := os.Getenv("GO111MODULE")
+	os.Setenv("GO111MODULE", "on")
+	defer os.Setenv("GO111MODULE", prev)
+	// Keep the test files so they are copied.
+	g.Check(os.MkdirAll(g.Path("co1/vendor"), 0777))
+	g.Check(ioutil.WriteFile(g.Path("co1/"+relVendorFile), []byte(`{"package": [], "rootPath": "co1"}`), 0666))
+
+	output := &bytes.Buffer{}
+	_, err := Run(output, []string{"testing", "add", "-test", "co2/pk1"}, &testPrompt{})
+	if err != nil {
+		g.Fatalf("passing tests: %v\n%s", err, output)
+	}
+	if !strings.Contains(output.String(), "ok") || !strings.Contains(output.String(), "co1/vendor/co2/pk1") {
+		g.Fatalf("passing tests not reported:\n%s", output)
+	}
+
+	output.Reset()
+	_, err = Run(output, []string{"testing", "add", "-test", "co2/pk2"}, &testPrompt{})
+	if err == nil || !strings.Contains(err.Error(), "tests failed for 1 copied package(s)") {
+		g.Fatalf("failing tests: got error %v\n%s", err, output)
+	}
+	if !strings.Contains(output.String(), "FAIL") {
+		g.Fatalf("failing tests not reported:\n%s", output)
+	}
+}
+
+func TestUninitialized(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	Vendor(g, "list before init", "list", `
+ e  co2/pk1
+ l  co1/pk1
+`)
+	Vendor(g, "status before init", "status", "")
+
+	_, err := Run(&bytes.Buffer{}, []string{"testing", "add", "+ext"}, &testPrompt{})
+	if err == nil {
+		g.Fatal("expected add to require init")
+	}
+	if _, err := os.Stat(filepath.Join(g.Current(), relVendorFile)); !os.IsNotExist(err) {
+		g.Fatal("vendor file should not be created by read only commands")
+	}
+}
+
+func TestInitEmpty(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	fp := filepath.Join(g.Current(), relVendorFile)
+	if err := os.MkdirAll(filepath.Dir(fp), 0777); err != nil {
+		g.Fatal(err)
+	}
+	if err := ioutil.WriteFile(fp, []byte("{}\n"), 0666); err != nil {
+		g.Fatal(err)
+	}
+	Vendor(g, "co1 init empty", "init", "")
+	vendorFile(g, `{
+	"comment": "",
+	"ignore": "test",
+	"package": [],
+	"rootPath": "co1"
+}`)
+
+	// Initializing again keeps the settings.
+	if err := ioutil.WriteFile(fp, []byte(`{"ignore": "appengine", "rootPath": "co1"}`), 0666); err != nil {
+		g.Fatal(err)
+	}
+	Vendor(g, "co1 init again", "init", "")
+	vendorFile(g, `{
+	"comment": "",
+	"ignore": "appengine",
+	"package": [],
+	"rootPath": "co1"
+}`)
+}
@@ -5,7 +5,6 @@
 package vcs
 
 import (
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -30,9 +29,7 @@ func (VcsBzr) Find(dir string) (*VcsInfo, error) {
 	// Get info.
 	info := &VcsInfo{}
 
-	cmd := exec.Command("bzr", "status")
-	cmd.Dir = dir
-	output, err := cmd.CombinedOutput()
+	output, err := run(dir, true, "bzr", "status")
 	if err != nil {
 		return nil, err
 	}
@@ -40,9 +37,7 @@ func (VcsBzr) Find(dir string) (*VcsInfo, error) {
 		info.Dirty = true
 	}
 
-	cmd = exec.Command("bzr", "log", "-r-1")
-	cmd.Dir = dir
-	output, err = cmd.CombinedOutput()
+	output, err = run(dir, true, "bzr", "log", "-r-1")
 	if err != nil {
 		return nil, err
 	}
@@ -5,7 +5,6 @@
 package vcs
 
 import (
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -15,7 +14,14 @@ import (
 
 type VcsGit struct{}
 
-func (VcsGit) Find(dir string) (*VcsInfo, error) {
+func (g VcsGit) Find(dir string) (*VcsInfo, error) {
+	return g.findPackage(dir, dir)
+}
+
+// findPackage is Find where only changes to files under packageDir mark
+// the package dirty. Changes elsewhere in the repository, such as build
+// output of another package, do not matter to the package.
+func (VcsGit) findPackage(dir, packageDir string) (*VcsInfo, error) {
 	fi, err := os.Stat(filepath.Join(dir, ".git"))
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -30,18 +36,19 @@ func (VcsGit) Find(dir string) (*VcsInfo, error) {
 	// Get info.
 	info := &VcsInfo{}
 
-	cmd := exec.Command("git", "status", "--short")
-	cmd.Dir = dir
-	err = cmd.Run()
+	// Any changed or untracked file in the package directory is listed.
+	output, err := run(packageDir, false, "git", "status", "--porcelain", "--", ".")
 	if err != nil {
+		if _, is := err.(ErrTimeout); is {
+			return nil, err
+		}
+		info.Dirty = true
+	}
+	if len(strings.TrimSpace(string(output))) != 0 {
 		info.Dirty = true
 	}
 
-	cmd = exec.Command("git", "show", "--pretty=format:%H@%ai", "-s")
-
-	cmd.Dir = dir
-	cmd.Stderr = nil
-	output, err := cmd.Output()
+	output, err = run(dir, false, "git", "show", "--pretty=format:%H@%ai", "-s")
 	if err != nil {
 		return nil, err
 	}
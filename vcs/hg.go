@@ -5,7 +5,6 @@
 package vcs
 
 import (
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -30,9 +29,7 @@ func (VcsHg) Find(dir string) (*VcsInfo, error) {
 	// Get info.
 	info := &VcsInfo{}
 
-	cmd := exec.Command("hg", "identify", "-i")
-	cmd.Dir = dir
-	output, err := cmd.CombinedOutput()
+	output, err := run(dir, true, "hg", "identify", "-i")
 	if err != nil {
 		return nil, err
 	}
@@ -42,9 +39,7 @@ func (VcsHg) Find(dir string) (*VcsInfo, error) {
 		rev = strings.TrimSuffix(rev, "+")
 	}
 
-	cmd = exec.Command("hg", "log", "-r", rev)
-	cmd.Dir = dir
-	output, err = cmd.CombinedOutput()
+	output, err = run(dir, true, "hg", "log", "-r", rev)
 	if err != nil {
 		return nil, err
 	}
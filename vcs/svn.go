@@ -6,7 +6,6 @@ package vcs
 
 import (
 	"encoding/xml"
-	"os/exec"
 	"path/filepath"
 	"time"
 
@@ -30,9 +29,7 @@ func (svn VcsSvn) Find(dir string) (*VcsInfo, error) {
 	// Get info.
 	info := &VcsInfo{}
 
-	cmd := exec.Command("svn", "info", "--xml")
-	cmd.Dir = dir
-	output, err := cmd.CombinedOutput()
+	output, err := run(dir, true, "svn", "info", "--xml")
 	if err != nil {
 		return nil, err
 	}
@@ -6,7 +6,11 @@
 package vcs
 
 import (
+	"bytes"
+	"fmt"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,6 +30,12 @@ type Vcs interface {
 	Find(dir string) (*VcsInfo, error)
 }
 
+// packageFinder is implemented by a Vcs that can limit the dirty check to
+// the package directory rather than the whole repository at dir.
+type packageFinder interface {
+	findPackage(dir, packageDir string) (*VcsInfo, error)
+}
+
 var vcsRegistry = []Vcs{
 	VcsGit{},
 	VcsHg{},
@@ -44,6 +54,58 @@ func RegisterVCS(vcs Vcs) {
 
 const looplimit = 10000
 
+// Timeout limits how long a single version control command may run before
+// it is killed. A zero or negative value disables the limit.
+var Timeout = 5 * time.Second
+
+// ErrTimeout is returned when a version control command did not complete
+// within Timeout.
+type ErrTimeout struct {
+	Dir     string
+	Cmd     string
+	Timeout time.Duration
+}
+
+func (err ErrTimeout) Error() string {
+	return fmt.Sprintf("Command %q in %q did not finish within %v.", err.Cmd, err.Dir, err.Timeout)
+}
+
+// run executes the named command in dir and returns stdout. If combined
+// is true stderr is included in the output.
+func run(dir string, combined bool, name string, args ...string) ([]byte, error) {
+	timeout := Timeout
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	buf := &bytes.Buffer{}
+	cmd.Stdout = buf
+	if combined {
+		cmd.Stderr = buf
+	}
+	err := cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		err = cmd.Wait()
+		return buf.Bytes(), err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err = <-done:
+		return buf.Bytes(), err
+	case <-timer.C:
+		// Wait does not return until the output is closed, which a child
+		// of the command such as ssh may keep open, so do not wait for it.
+		cmd.Process.Kill()
+		return nil, ErrTimeout{Dir: dir, Cmd: name + " " + strings.Join(args, " "), Timeout: timeout}
+	}
+}
+
 // FindVcs determines the version control information given a package dir and
 // lowest root dir.
 func FindVcs(root, packageDir string) (info *VcsInfo, err error) {
@@ -56,7 +118,11 @@ func FindVcs(root, packageDir string) (info *VcsInfo, err error) {
 	path := packageDir
 	for i := 0; i <= looplimit; i++ {
 		for _, vcs := range vcsRegistry {
-			info, err = vcs.Find(path)
+			if pf, is := vcs.(packageFinder); is {
+				info, err = pf.findPackage(path, packageDir)
+			} else {
+				info, err = vcs.Find(path)
+			}
 			if err != nil {
 				return nil, err
 			}
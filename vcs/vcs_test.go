@@ -0,0 +1,145 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vcs
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunTimeout(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep command not available")
+	}
+	prev := Timeout
+	Timeout = 50 * time.Millisecond
+	defer func() { Timeout = prev }()
+
+	_, err := run("", false, "sleep", "5")
+	if _, is := err.(ErrTimeout); !is {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	Timeout = time.Second
+	if err.(ErrTimeout).Timeout != 50*time.Millisecond {
+		t.Fatalf("timeout not kept in error: %v", err)
+	}
+}
+
+func TestRunTimeoutChild(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh command not available")
+	}
+	prev := Timeout
+	Timeout = 50 * time.Millisecond
+	defer func() { Timeout = prev }()
+
+	// The sleep child keeps the output open after the shell is killed.
+	start := time.Now()
+	_, err := run("", false, "sh", "-c", "sleep 5; echo done")
+	if _, is := err.(ErrTimeout); !is {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("run returned after %v", d)
+	}
+}
+
+func TestGitDirty(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git command not available")
+	}
+	dir, err := ioutil.TempDir("", "govendor-vcs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	git := func(args ...string) {
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)
+		if out, err := run(dir, true, "git", args...); err != nil {
+			t.Fatalf("git %q: %v\n%s", args, err, out)
+		}
+	}
+	p := filepath.Join(dir, "a.go")
+	if err := ioutil.WriteFile(p, []byte("package a\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	git("init", "-q")
+	git("add", "a.go")
+	git("commit", "-q", "-m", "first")
+
+	info, err := VcsGit{}.Find(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Dirty {
+		t.Fatal("clean working tree reported as dirty")
+	}
+	if err := ioutil.WriteFile(p, []byte("package a // changed\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	info, err = VcsGit{}.Find(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Dirty {
+		t.Fatal("changed working tree not reported as dirty")
+	}
+}
+
+func TestGitDirtyOtherPackage(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git command not available")
+	}
+	dir, err := ioutil.TempDir("", "govendor-vcs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	git := func(args ...string) {
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)
+		if out, err := run(dir, true, "git", args...); err != nil {
+			t.Fatalf("git %q: %v\n%s", args, err, out)
+		}
+	}
+	for _, name := range []string{"a", "b"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name, "a.go"), []byte("package "+name+"\n"), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+
+	// An untracked file and a change in package b.
+	if err := ioutil.WriteFile(filepath.Join(dir, "b", "build.out"), []byte("x"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "b", "a.go"), []byte("package b // changed\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	info, err := FindVcs(dir, filepath.Join(dir, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil || info.Dirty {
+		t.Fatalf("package a reported as dirty: %+v", info)
+	}
+	info, err = FindVcs(dir, filepath.Join(dir, "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil || !info.Dirty {
+		t.Fatalf("package b not reported as dirty: %+v", info)
+	}
+}
@@ -13,7 +13,9 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"reflect"
 	"sort"
+	"unicode/utf8"
 )
 
 // Name of the vendor file.
@@ -29,6 +31,10 @@ type File struct {
 
 	Package []*Package
 
+	// CRLF writes lines ending in "\r\n" instead of "\n". Set when
+	// reading a file that uses them, so the line endings are kept.
+	CRLF bool
+
 	// all preserves unknown values.
 	all map[string]interface{}
 }
@@ -53,6 +59,38 @@ type Package struct {
 	VersionExact string
 	ChecksumSHA1 string
 	Comment      string
+
+	// Upstream is the canonical import path of the package when it is
+	// copied from a fork given in Origin. Used to look for new releases.
+	Upstream string
+
+	// Files is an optional manifest of each file in the package.
+	Files []FileChecksum
+
+	// History optionally records previous revisions, oldest first.
+	History []HistoryEntry
+
+	// Exclude lists globs of directories, relative to the package,
+	// that are not copied into a tree package.
+	Exclude []string
+
+	// MaxFileSize is the size in bytes above which a non-Go file of the
+	// package is not copied. Zero has no limit.
+	MaxFileSize int64
+}
+
+// HistoryEntry records a revision a package was at before it changed.
+type HistoryEntry struct {
+	Revision     string
+	RevisionTime string
+	Version      string
+	Changed      string // Time the package moved off this revision, RFC3339.
+}
+
+// FileChecksum records the checksum of a single file in a package.
+type FileChecksum struct {
+	Path         string // Slash separated path relative to the package.
+	ChecksumSHA1 string
 }
 
 func (pkg *Package) PathOrigin() string {
@@ -94,6 +132,12 @@ var (
 	versionExactNames = []string{"versionExact"}
 	checksumSHA1Names = []string{"checksumSHA1"}
 	commentNames      = []string{"comment", "Comment"}
+	filesNames        = []string{"files"}
+	historyNames      = []string{"history"}
+	changedNames      = []string{"changed"}
+	excludeNames      = []string{"exclude"}
+	maxFileSizeNames  = []string{"maxFileSize"}
+	upstreamNames     = []string{"upstream"}
 )
 
 type vendorPackageSort []interface{}
@@ -142,6 +186,15 @@ loop:
 			if value {
 				break loop
 			}
+		case *int64:
+			value, is := raw.(float64)
+			if !is {
+				continue loop
+			}
+			*field = int64(value)
+			if value != 0 {
+				break loop
+			}
 		}
 	}
 }
@@ -166,6 +219,14 @@ func setObject(fieldObj interface{}, object map[string]interface{}, names []stri
 			}
 			object[name] = field
 		}
+	case int64:
+		for i, name := range names {
+			if i != 0 || (hideEmpty && field == 0) {
+				delete(object, name)
+				continue
+			}
+			object[name] = field
+		}
 	}
 }
 
@@ -217,9 +278,50 @@ func (vf *File) toFields() {
 		setField(&pkg.VersionExact, object, versionExactNames)
 		setField(&pkg.ChecksumSHA1, object, checksumSHA1Names)
 		setField(&pkg.Comment, object, commentNames)
+		setField(&pkg.Upstream, object, upstreamNames)
+		pkg.Files = getFiles(object)
+		pkg.History = getHistory(object)
+		pkg.Exclude = getStrings(object, excludeNames)
+		setField(&pkg.MaxFileSize, object, maxFileSizeNames)
 	}
 }
 
+// getFiles reads the file manifest from a raw package object.
+func getFiles(object map[string]interface{}) []FileChecksum {
+	rawList, _ := object[filesNames[0]].([]interface{})
+	if len(rawList) == 0 {
+		return nil
+	}
+	files := make([]FileChecksum, 0, len(rawList))
+	for _, rawFile := range rawList {
+		fileObject, is := rawFile.(map[string]interface{})
+		if !is {
+			continue
+		}
+		fc := FileChecksum{}
+		setField(&fc.Path, fileObject, pathNames[:1])
+		setField(&fc.ChecksumSHA1, fileObject, checksumSHA1Names)
+		files = append(files, fc)
+	}
+	return files
+}
+
+// setFiles writes the file manifest to a raw package object.
+func setFiles(files []FileChecksum, object map[string]interface{}) {
+	if len(files) == 0 {
+		delete(object, filesNames[0])
+		return
+	}
+	rawList := make([]interface{}, len(files))
+	for i, fc := range files {
+		rawList[i] = map[string]interface{}{
+			pathNames[0]:         fc.Path,
+			checksumSHA1Names[0]: fc.ChecksumSHA1,
+		}
+	}
+	object[filesNames[0]] = rawList
+}
+
 // toAll moves values from field values to "all".
 func (vf *File) toAll() {
 	delete(vf.all, "Tool")
@@ -246,6 +348,11 @@ func (vf *File) toAll() {
 		setObject(pkg.VersionExact, pkg.field, versionExactNames, true)
 		setObject(pkg.ChecksumSHA1, pkg.field, checksumSHA1Names, true)
 		setObject(pkg.Comment, pkg.field, commentNames, true)
+		setObject(pkg.Upstream, pkg.field, upstreamNames, true)
+		setFiles(pkg.Files, pkg.field)
+		setHistory(pkg.History, pkg.field)
+		setStrings(pkg.Exclude, pkg.field, excludeNames)
+		setObject(pkg.MaxFileSize, pkg.field, maxFileSizeNames, true)
 	}
 
 	for i := len(vf.Package) - 1; i >= 0; i-- {
@@ -259,7 +366,7 @@ func (vf *File) toAll() {
 				}
 				same := true
 				for key, value := range pkg.field {
-					if raw[key] != value {
+					if !reflect.DeepEqual(raw[key], value) {
 						same = false
 						break
 					}
@@ -291,6 +398,98 @@ func (vf *File) toAll() {
 	vf.all[packageNames[0]] = nextRawPackageList
 }
 
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// EncodingError is returned from Unmarshal if the vendor file is not
+// valid UTF-8.
+type EncodingError struct {
+	Offset int // Byte offset of the first invalid byte.
+}
+
+func (err EncodingError) Error() string {
+	return fmt.Sprintf("Invalid UTF-8 at byte offset %d.", err.Offset)
+}
+
+// checkUTF8 returns an EncodingError if bb is not valid UTF-8 after start.
+func checkUTF8(bb []byte, start int) error {
+	for i := start; i < len(bb); {
+		r, size := utf8.DecodeRune(bb[i:])
+		if r == utf8.RuneError && size <= 1 {
+			return EncodingError{Offset: i}
+		}
+		i += size
+	}
+	return nil
+}
+
+// getHistory reads the revision history from a raw package object.
+func getHistory(object map[string]interface{}) []HistoryEntry {
+	rawList, _ := object[historyNames[0]].([]interface{})
+	if len(rawList) == 0 {
+		return nil
+	}
+	history := make([]HistoryEntry, 0, len(rawList))
+	for _, rawEntry := range rawList {
+		entryObject, is := rawEntry.(map[string]interface{})
+		if !is {
+			continue
+		}
+		he := HistoryEntry{}
+		setField(&he.Revision, entryObject, revisionNames[:1])
+		setField(&he.RevisionTime, entryObject, revisionTimeNames[:1])
+		setField(&he.Version, entryObject, versionNames)
+		setField(&he.Changed, entryObject, changedNames)
+		history = append(history, he)
+	}
+	return history
+}
+
+// setHistory writes the revision history to a raw package object.
+func setHistory(history []HistoryEntry, object map[string]interface{}) {
+	if len(history) == 0 {
+		delete(object, historyNames[0])
+		return
+	}
+	rawList := make([]interface{}, len(history))
+	for i, he := range history {
+		entryObject := make(map[string]interface{}, 4)
+		setObject(he.Revision, entryObject, revisionNames[:1], false)
+		setObject(he.RevisionTime, entryObject, revisionTimeNames[:1], true)
+		setObject(he.Version, entryObject, versionNames, true)
+		setObject(he.Changed, entryObject, changedNames, true)
+		rawList[i] = entryObject
+	}
+	object[historyNames[0]] = rawList
+}
+
+// getStrings reads a list of strings from a raw object.
+func getStrings(object map[string]interface{}, names []string) []string {
+	rawList, _ := object[names[0]].([]interface{})
+	if len(rawList) == 0 {
+		return nil
+	}
+	list := make([]string, 0, len(rawList))
+	for _, raw := range rawList {
+		if s, is := raw.(string); is {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// setStrings writes a list of strings to a raw object.
+func setStrings(list []string, object map[string]interface{}, names []string) {
+	if len(list) == 0 {
+		delete(object, names[0])
+		return
+	}
+	rawList := make([]interface{}, len(list))
+	for i, s := range list {
+		rawList[i] = s
+	}
+	object[names[0]] = rawList
+}
+
 // Marshal the vendor file to the specified writer.
 // Retains read fields.
 func (vf *File) Marshal(w io.Writer) error {
@@ -311,10 +510,22 @@ func (vf *File) Marshal(w io.Writer) error {
 	if err != nil {
 		return err
 	}
+	if vf.CRLF {
+		// Strings in JSON can't hold a raw new line, only line ends match.
+		_, err = w.Write(bytes.Replace(buf.Bytes(), []byte("\n"), []byte("\r\n"), -1))
+		return err
+	}
 	_, err = io.Copy(w, buf)
 	return err
 }
 
+// Empty reports if the file has no settings and no packages, such as a
+// blank file or "{}". Such a file is a placeholder that was never
+// initialized.
+func (vf *File) Empty() bool {
+	return len(vf.all) == 0 && len(vf.RootPath) == 0 && len(vf.Comment) == 0 && len(vf.Ignore) == 0 && len(vf.Package) == 0
+}
+
 // Unmarshal the vendor file from the specified reader.
 // Stores internally all fields.
 func (vf *File) Unmarshal(r io.Reader) error {
@@ -322,10 +533,25 @@ func (vf *File) Unmarshal(r io.Reader) error {
 	if err != nil {
 		return err
 	}
+	// Some editors save a byte order mark, remove it.
+	offset := 0
+	if bytes.HasPrefix(bb, utf8BOM) {
+		offset = len(utf8BOM)
+	}
+	if err = checkUTF8(bb, offset); err != nil {
+		return err
+	}
+	bb = bb[offset:]
+	vf.CRLF = bytes.Contains(bb, []byte("\r\n"))
 
 	if vf.all == nil {
 		vf.all = make(map[string]interface{}, 3)
 	}
+	// A blank file is read as an empty file so it may be initialized.
+	if len(bytes.TrimSpace(bb)) == 0 {
+		vf.toFields()
+		return nil
+	}
 	err = json.Unmarshal(bb, &vf.all)
 	if err != nil {
 		return err
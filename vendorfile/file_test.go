@@ -152,3 +152,243 @@ func TestAdd(t *testing.T) {
 		t.Fatal("Got:", buf.String())
 	}
 }
+
+func TestNormalize(t *testing.T) {
+	var from = `{
+	"package": [
+		{
+			"path": "pkg2",
+			"revision": " abc "
+		},
+		{
+			"path": "pkg1\\sub/"
+		},
+		{
+			"path": "pkg2",
+			"revision": " abc "
+		}
+	]
+}`
+	var to = `{
+	"comment": "",
+	"ignore": "",
+	"package": [
+		{
+			"path": "pkg1/sub",
+			"revision": ""
+		},
+		{
+			"path": "pkg2",
+			"revision": "abc"
+		}
+	]
+}`
+
+	vf := &File{}
+
+	err := vf.Unmarshal(strings.NewReader(from))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = vf.Normalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vf.Package) != 2 || vf.Package[0].Path != "pkg1/sub" {
+		t.Fatalf("Got packages: %v", packageList(vf.Package))
+	}
+
+	buf := &bytes.Buffer{}
+	err = vf.Marshal(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != to {
+		t.Fatal("Got:", buf.String())
+	}
+
+	vf.Package = append(vf.Package, &Package{Add: true, Path: "a/../b"})
+	if _, is := vf.Normalize().(ValidationErrors); !is {
+		t.Fatal("expected validation error for unclean path")
+	}
+}
+
+func TestNormalizeTime(t *testing.T) {
+	list := []struct{ In, Out string }{
+		{"", ""},
+		{"2015-04-10T19:48:00+02:00", "2015-04-10T17:48:00Z"},
+		{"2015-04-10T17:48:00.123Z", "2015-04-10T17:48:00Z"},
+		{"2015-04-10 19:48:00 +0200", "2015-04-10T17:48:00Z"},
+		{" Fri Apr 10 19:48:00 2015 +0200 ", "2015-04-10T17:48:00Z"},
+		{"Fri, 10 Apr 2015 19:48:00 +0200", "2015-04-10T17:48:00Z"},
+		{"yesterday", "yesterday"},
+	}
+	for _, item := range list {
+		if got := normalizeTime(item.In); got != item.Out {
+			t.Errorf("for %q got %q, want %q", item.In, got, item.Out)
+		}
+	}
+
+	vf := &File{Package: []*Package{{
+		Path:         "pkg1",
+		RevisionTime: "2015-04-10 19:48:00 +0200",
+		History:      []HistoryEntry{{Revision: "abc", Changed: "2016-02-01T01:00:00+01:00"}},
+	}}}
+	if err := vf.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if got := vf.Package[0].RevisionTime; got != "2015-04-10T17:48:00Z" {
+		t.Fatalf("got revision time %q", got)
+	}
+	if got := vf.Package[0].History[0].Changed; got != "2016-02-01T00:00:00Z" {
+		t.Fatalf("got history changed %q", got)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	var from = `{
+	"comment": "",
+	"ignore": "",
+	"package": [
+		{
+			"files": [
+				{
+					"checksumSHA1": "abc=",
+					"path": "a.go"
+				}
+			],
+			"path": "pkg1",
+			"revision": ""
+		}
+	]
+}`
+
+	vf := &File{}
+
+	err := vf.Unmarshal(strings.NewReader(from))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vf.Package[0].Files) != 1 || vf.Package[0].Files[0].Path != "a.go" {
+		t.Fatalf("Got files: %v", vf.Package[0].Files)
+	}
+
+	buf := &bytes.Buffer{}
+	err = vf.Marshal(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != from {
+		t.Fatal("Got:", buf.String())
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	vf := &File{}
+	err := vf.Unmarshal(strings.NewReader("\xEF\xBB\xBF{\"comment\": \"bom\"}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vf.Comment != "bom" {
+		t.Fatalf("Got comment %q", vf.Comment)
+	}
+
+	err = (&File{}).Unmarshal(strings.NewReader("{\"comment\": \"\xff\"}"))
+	if encErr, is := err.(EncodingError); !is || encErr.Offset != 13 {
+		t.Fatalf("Got error %v", err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	var from = `{
+	"comment": "",
+	"ignore": "",
+	"package": [
+		{
+			"history": [
+				{
+					"changed": "2016-02-01T00:00:00Z",
+					"revision": "abc",
+					"revisionTime": "2016-01-01T00:00:00Z"
+				}
+			],
+			"path": "pkg1",
+			"revision": "def"
+		}
+	]
+}`
+
+	vf := &File{}
+
+	err := vf.Unmarshal(strings.NewReader(from))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vf.Package[0].History) != 1 || vf.Package[0].History[0].Revision != "abc" {
+		t.Fatalf("Got history: %v", vf.Package[0].History)
+	}
+
+	buf := &bytes.Buffer{}
+	err = vf.Marshal(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != from {
+		t.Fatal("Got:", buf.String())
+	}
+}
+
+func TestLineEnding(t *testing.T) {
+	const from = "{\r\n\t\"comment\": \"\",\r\n\t\"ignore\": \"\",\r\n\t\"package\": []\r\n}"
+
+	vf := &File{}
+	err := vf.Unmarshal(strings.NewReader(from))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !vf.CRLF {
+		t.Fatal("CRLF line endings not found")
+	}
+	buf := &bytes.Buffer{}
+	err = vf.Marshal(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != from {
+		t.Fatalf("Got: %q", buf.String())
+	}
+
+	vf.CRLF = false
+	buf.Reset()
+	err = vf.Marshal(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "\r") {
+		t.Fatalf("Got: %q", buf.String())
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	for _, from := range []string{"", " \n", "{}", "{\n}\n"} {
+		vf := &File{}
+		err := vf.Unmarshal(strings.NewReader(from))
+		if err != nil {
+			t.Fatalf("%q: %v", from, err)
+		}
+		if !vf.Empty() {
+			t.Fatalf("%q: not empty", from)
+		}
+	}
+	vf := &File{}
+	err := vf.Unmarshal(strings.NewReader(`{"ignore": "test"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vf.Empty() {
+		t.Fatal("initialized file is empty")
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vendorfile
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ValidationError describes a problem with a package entry that
+// Normalize cannot fix.
+type ValidationError struct {
+	Path string
+	Msg  string
+}
+
+func (err ValidationError) Error() string {
+	return fmt.Sprintf("Package %q: %s.", err.Path, err.Msg)
+}
+
+// ValidationErrors is returned from Normalize.
+type ValidationErrors []ValidationError
+
+func (list ValidationErrors) Error() string {
+	if len(list) == 0 {
+		return "(no validation error)"
+	}
+	buf := &bytes.Buffer{}
+	buf.WriteString("Invalid vendor file:\n")
+	for _, item := range list {
+		buf.WriteString("\t")
+		buf.WriteString(item.Error())
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+type packageSort []*Package
+
+func (list packageSort) Len() int      { return len(list) }
+func (list packageSort) Swap(i, j int) { list[i], list[j] = list[j], list[i] }
+func (list packageSort) Less(i, j int) bool {
+	if list[i].Path == list[j].Path {
+		return len(list[i].Origin) > len(list[j].Origin)
+	}
+	return list[i].Path < list[j].Path
+}
+
+type fileChecksumSort []FileChecksum
+
+func (list fileChecksumSort) Len() int           { return len(list) }
+func (list fileChecksumSort) Swap(i, j int)      { list[i], list[j] = list[j], list[i] }
+func (list fileChecksumSort) Less(i, j int) bool { return list[i].Path < list[j].Path }
+
+func cleanPath(p string) string {
+	p = strings.TrimSpace(p)
+	p = strings.Replace(p, `\`, "/", -1)
+	return strings.Trim(p, "/")
+}
+
+// timeFormats are the time formats accepted for revision times. The first
+// is the format written.
+var timeFormats = []string{
+	time.RFC3339,
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05 -0700",     // git log --date=iso
+	"Mon Jan 2 15:04:05 2006 -0700", // git log --date=default
+	time.RFC1123Z,
+	time.RFC1123,
+	time.UnixDate,
+	time.RubyDate,
+}
+
+// normalizeTime puts a time in UTC RFC3339 form. A time in an unknown
+// format is returned trimmed but otherwise unchanged.
+func normalizeTime(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) == 0 {
+		return s
+	}
+	for _, format := range timeFormats {
+		if t, err := time.Parse(format, s); err == nil {
+			return t.UTC().Format(timeFormats[0])
+		}
+	}
+	return s
+}
+
+// Normalize puts the file into canonical form in place. Packages are sorted,
+// paths are put in slash form, times are put in UTC RFC3339 form, whitespace
+// is trimmed and duplicate entries are removed. Problems that can't be fixed are returned as ValidationErrors.
+func (vf *File) Normalize() error {
+	vf.RootPath = cleanPath(vf.RootPath)
+	vf.Comment = strings.TrimSpace(vf.Comment)
+	vf.Ignore = strings.TrimSpace(vf.Ignore)
+
+	var verr ValidationErrors
+	seen := make(map[[2]string]bool, len(vf.Package))
+	list := make([]*Package, 0, len(vf.Package))
+	for _, pkg := range vf.Package {
+		if pkg == nil {
+			continue
+		}
+		if pkg.Remove {
+			list = append(list, pkg)
+			continue
+		}
+		pkg.Origin = cleanPath(pkg.Origin)
+		pkg.Path = cleanPath(pkg.Path)
+		pkg.Revision = strings.TrimSpace(pkg.Revision)
+		pkg.RevisionTime = normalizeTime(pkg.RevisionTime)
+		pkg.Version = strings.TrimSpace(pkg.Version)
+		pkg.VersionExact = strings.TrimSpace(pkg.VersionExact)
+		pkg.ChecksumSHA1 = strings.TrimSpace(pkg.ChecksumSHA1)
+		pkg.Comment = strings.TrimSpace(pkg.Comment)
+		pkg.Upstream = cleanPath(pkg.Upstream)
+		for i := range pkg.Files {
+			pkg.Files[i].Path = cleanPath(pkg.Files[i].Path)
+			pkg.Files[i].ChecksumSHA1 = strings.TrimSpace(pkg.Files[i].ChecksumSHA1)
+		}
+		sort.Sort(fileChecksumSort(pkg.Files))
+		for i := range pkg.History {
+			pkg.History[i].RevisionTime = normalizeTime(pkg.History[i].RevisionTime)
+			pkg.History[i].Changed = normalizeTime(pkg.History[i].Changed)
+		}
+		if pkg.Origin == pkg.Path {
+			pkg.Origin = ""
+		}
+
+		key := [2]string{pkg.Path, pkg.Origin}
+		if seen[key] {
+			vf.removeRaw(pkg.field)
+			continue
+		}
+		seen[key] = true
+		list = append(list, pkg)
+
+		if len(pkg.Path) == 0 {
+			verr = append(verr, ValidationError{Path: pkg.Origin, Msg: "missing path"})
+			continue
+		}
+		for _, part := range strings.Split(pkg.Path, "/") {
+			if part == "." || part == ".." || len(part) == 0 {
+				verr = append(verr, ValidationError{Path: pkg.Path, Msg: "path must be a clean import path"})
+				break
+			}
+		}
+		if len(pkg.ChecksumSHA1) != 0 {
+			if _, err := base64.StdEncoding.DecodeString(pkg.ChecksumSHA1); err != nil {
+				verr = append(verr, ValidationError{Path: pkg.Path, Msg: "checksumSHA1 is not valid base64"})
+			}
+		}
+	}
+	sort.Stable(packageSort(list))
+	vf.Package = list
+	if len(verr) == 0 {
+		return nil
+	}
+	return verr
+}
+
+// removeRaw removes the raw package object from the unknown values.
+func (vf *File) removeRaw(field map[string]interface{}) {
+	if field == nil {
+		return
+	}
+	rawPackageList := vf.getRawPackageList()
+	ptr := reflect.ValueOf(field).Pointer()
+	for index, rawObj := range rawPackageList {
+		raw, is := rawObj.(map[string]interface{})
+		if !is || reflect.ValueOf(raw).Pointer() != ptr {
+			continue
+		}
+		vf.all[packageNames[0]] = append(rawPackageList[:index:index], rawPackageList[index+1:]...)
+		return
+	}
+}